@@ -1,7 +1,7 @@
 package main
 
 import (
-	"encoding/xml"
+	"bytes"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -38,28 +38,63 @@ var routes = []string{
 
 func main() {
 	for _, r := range routes {
-		gomap := makeRequest(gomapBaseURL + r)
-		cgimap := makeRequest(cgimapBaseURL + r)
-		isEqual := gomap.Equals(cgimap)
-		log.Printf("%v: %v", r, isEqual)
+		gomapBody, gomap := makeRequest(gomapBaseURL + r)
+		cgimapBody, cgimap := makeRequest(cgimapBaseURL + r)
+		isIdentical := bytes.Equal(gomapBody, cgimapBody)
+		isEqual := isIdentical || gomap.Equals(cgimap)
+		log.Printf("%v: equal %v, identical %v", r, isEqual, isIdentical)
 		if !isEqual {
-			log.Println(gomap)
-			log.Println(cgimap)
+			printDiff(gomap, cgimap)
 		}
 	}
 }
 
-func makeRequest(url string) *osm.OSM {
+// makeRequest returns the raw response body and decoded elements
+func makeRequest(url string) ([]byte, *osm.OSM) {
 	res, err := http.Get(url)
 	if err != nil {
 		log.Fatal(err)
 	}
-	response, err := ioutil.ReadAll(res.Body)
-	res.Body.Close()
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("%v: %v", url, err)
 	}
+
 	o := &osm.OSM{}
-	xml.Unmarshal(response, o)
-	return o
+	if err := osm.Copy(o, osm.NewXMLScanner(bytes.NewReader(body))); err != nil {
+		log.Fatalf("%v: %v", url, err)
+	}
+	return body, o
+}
+
+// printDiff prints OPL lines which are present only in one of responses
+func printDiff(gomap, cgimap *osm.OSM) {
+	gomapLines := oplLines(gomap)
+	cgimapLines := oplLines(cgimap)
+	for l := range gomapLines {
+		if !cgimapLines[l] {
+			log.Printf("- %s", l)
+		}
+	}
+	for l := range cgimapLines {
+		if !gomapLines[l] {
+			log.Printf("+ %s", l)
+		}
+	}
+}
+
+func oplLines(o *osm.OSM) map[string]bool {
+	data, err := o.MarshalOPL()
+	if err != nil {
+		log.Fatal(err)
+	}
+	lines := map[string]bool{}
+	for _, l := range bytes.Split(data, []byte("\n")) {
+		if len(l) != 0 {
+			lines[string(l)] = true
+		}
+	}
+	return lines
 }
@@ -21,12 +21,14 @@ func main() {
 			User:     "heorhi",
 			Password: "some_password",
 		},
+		MinTileZoom: 12,
+		MaxMapArea:  0.25,
 	}
 	db, err := db.Init(config.Database)
 	if err != nil {
 		log.Fatalf("DB started with error: %v", err)
 	}
-	g := gomap.New(db)
+	g := gomap.New(db, config)
 	server := server.New(g)
 	router := router.Load(config, server)
 	err = router.Start(":" + config.Port)
@@ -4,6 +4,12 @@ package config
 type Config struct {
 	Port     string
 	Database DB
+	// MinTileZoom is the lowest zoom of vector tiles,
+	// lower zooms would require too big map queries
+	MinTileZoom uint32
+	// MaxMapArea is the largest area of map requests in square degrees,
+	// zero means no limit
+	MaxMapArea float64
 }
 
 // DB contains database credentials
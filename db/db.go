@@ -26,6 +26,8 @@ const (
 	stmtSelectHistoricalWays       = "select_historical_ways"
 	stmtSelectHistoricalRelations  = "select_historical_relations"
 	stmtSelectNodesFromBbox        = "visible_node_in_bbox"
+	stmtNodeCoordinatesInBbox      = "visible_node_coordinates_in_bbox"
+	stmtNodeCoordinatesInBboxAfter = "visible_node_coordinates_in_bbox_after"
 	stmtExtractChangesets          = "extract_changesets"
 	stmtExtractNodes               = "extract_nodes"
 	stmtExtractWays                = "extract_ways"
@@ -41,6 +43,24 @@ const (
 	stmtRelationParentsOfNodes     = "relation_parents_of_nodes"
 	stmtRelationParentsOfWays      = "relation_parents_of_ways"
 	stmtRelationParentsOfRelations = "relation_parents_of_relations"
+	stmtHistoricNodesInBbox        = "historic_nodes_in_bbox"
+	stmtHistoricNodesFromWays      = "historic_nodes_from_ways"
+	stmtHistoricWaysFromNodes      = "historic_ways_from_nodes"
+	stmtHistoricRelationParents    = "historic_relation_parents"
+	stmtNodeVersionsAt             = "node_versions_at"
+	stmtWayVersionsAt              = "way_versions_at"
+	stmtRelationVersionsAt         = "relation_versions_at"
+	stmtNodeHistoryRange           = "node_history_range"
+	stmtWayHistoryRange            = "way_history_range"
+	stmtRelationHistoryRange       = "relation_history_range"
+	stmtWayVersionsFromNode        = "way_versions_from_node"
+	stmtRelationVersionsFromMember = "relation_versions_from_member"
+	stmtNodesWithTag               = "nodes_with_tag"
+	stmtWaysWithTag                = "ways_with_tag"
+	stmtRelationsWithTag           = "relations_with_tag"
+	stmtFilterNodesWithTag         = "filter_nodes_with_tag"
+	stmtFilterWaysWithTag          = "filter_ways_with_tag"
+	stmtFilterRelationsWithTag     = "filter_relations_with_tag"
 )
 
 // OsmDB contains logic to deal with Openstreetmap database
@@ -268,7 +288,9 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 		strings.TrimSpace(`
 			SELECT DISTINCT wn.way_id AS id
 			FROM current_way_nodes wn
-			WHERE wn.node_id = ANY($1)
+			JOIN current_ways w ON w.id = wn.way_id
+			WHERE w.visible = true AND
+				  wn.node_id = ANY($1)
 		`),
 	); err != nil {
 		return nil, err
@@ -277,12 +299,48 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 	if _, err := conn.Prepare(
 		stmtSelectNodesFromBbox,
 		strings.TrimSpace(`
-			SELECT id
-			FROM current_nodes
-			WHERE latitude BETWEEN $1 AND $2 AND 
-				  longitude BETWEEN $3 AND $4 AND 
-				  visible = true
-			LIMIT $5
+			SELECT n.id
+			FROM current_nodes n
+			JOIN unnest($1::bigint[], $2::bigint[]) AS t(min_tile, max_tile)
+				ON n.tile BETWEEN t.min_tile AND t.max_tile
+			WHERE n.latitude BETWEEN $3 AND $4 AND
+				  n.longitude BETWEEN $5 AND $6 AND
+				  n.visible = true
+			LIMIT $7
+		`),
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Prepare(
+		stmtNodeCoordinatesInBbox,
+		strings.TrimSpace(`
+			SELECT n.id, n.latitude, n.longitude
+			FROM current_nodes n
+			JOIN unnest($1::bigint[], $2::bigint[]) AS t(min_tile, max_tile)
+				ON n.tile BETWEEN t.min_tile AND t.max_tile
+			WHERE n.latitude BETWEEN $3 AND $4 AND
+				  n.longitude BETWEEN $5 AND $6 AND
+				  n.visible = true
+			LIMIT $7
+		`),
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Prepare(
+		stmtNodeCoordinatesInBboxAfter,
+		strings.TrimSpace(`
+			SELECT n.id, n.latitude, n.longitude
+			FROM current_nodes n
+			JOIN unnest($1::bigint[], $2::bigint[]) AS t(min_tile, max_tile)
+				ON n.tile BETWEEN t.min_tile AND t.max_tile
+			WHERE n.latitude BETWEEN $3 AND $4 AND
+				  n.longitude BETWEEN $5 AND $6 AND
+				  n.visible = true AND
+				  n.id > $7
+			ORDER BY n.id
+			LIMIT $8
 		`),
 	); err != nil {
 		return nil, err
@@ -507,6 +565,7 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 					   n.version = x.version 
 			JOIN changesets c ON c.id = n.changeset_id
 			LEFT JOIN users u ON (u.id = c.user_id and u.data_public)
+			WHERE n.redaction_id IS NULL
 			ORDER BY n.node_id, n.version
 		`),
 	); err != nil {
@@ -555,6 +614,7 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 			) wn ON true
 			JOIN changesets c ON c.id = w.changeset_id
     		LEFT JOIN users u ON (u.id = c.user_id and u.data_public)
+			WHERE w.redaction_id IS NULL
 			ORDER BY w.way_id, w.version
 		`),
 	); err != nil {
@@ -614,6 +674,7 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 			) rm ON true
 			JOIN changesets c ON c.id = r.changeset_id
     		LEFT JOIN users u ON (u.id = c.user_id and u.data_public)
+			WHERE r.redaction_id IS NULL
 			ORDER BY r.relation_id, r.version
 		`),
 	); err != nil {
@@ -648,7 +709,9 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 		strings.TrimSpace(`
 			SELECT DISTINCT wn.way_id AS id
 			FROM current_way_nodes wn
-			WHERE wn.node_id = ANY($1)
+			JOIN current_ways w ON w.id = wn.way_id
+			WHERE w.visible = true AND
+				  wn.node_id = ANY($1)
 		`),
 	); err != nil {
 		return nil, err
@@ -683,7 +746,9 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 		strings.TrimSpace(`
 			SELECT DISTINCT rm.relation_id AS id
 			FROM current_relation_members rm
-			WHERE rm.member_type = 'Node' AND 
+			JOIN current_relations r ON r.id = rm.relation_id
+			WHERE r.visible = true AND
+				  rm.member_type = 'Node' AND
 				  rm.member_id = ANY($1)
 		`),
 	); err != nil {
@@ -695,7 +760,9 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 		strings.TrimSpace(`
 			SELECT DISTINCT rm.relation_id AS id
 			FROM current_relation_members rm
-			WHERE rm.member_type = 'Way' AND 
+			JOIN current_relations r ON r.id = rm.relation_id
+			WHERE r.visible = true AND
+				  rm.member_type = 'Way' AND
 				  rm.member_id = ANY($1)
 		`),
 	); err != nil {
@@ -707,12 +774,257 @@ func initStatements(conn *pgx.ConnPool) (map[string]*pgx.PreparedStatement, erro
 		strings.TrimSpace(`
 			SELECT DISTINCT rm.relation_id AS id
 			FROM current_relation_members rm
-			WHERE rm.member_type = 'Relation' AND 
+			JOIN current_relations r ON r.id = rm.relation_id
+			WHERE r.visible = true AND
+				  rm.member_type = 'Relation' AND
 				  rm.member_id = ANY($1)
 		`),
 	); err != nil {
 		return nil, err
 	}
 
+	if err := initHistoricStatements(conn); err != nil {
+		return nil, err
+	}
+
+	if err := initTagStatements(conn); err != nil {
+		return nil, err
+	}
+
 	return sts, nil
 }
+
+// initHistoricStatements prepares statements selecting elements
+// as they were at a past timestamp from the history tables.
+// Redacted versions are skipped, the latest version which isn't redacted is used.
+func initHistoricStatements(conn *pgx.ConnPool) error {
+	if _, err := conn.Prepare(
+		stmtHistoricNodesInBbox,
+		strings.TrimSpace(`
+			WITH candidates AS (
+				SELECT DISTINCT n.node_id
+				FROM nodes n
+				JOIN unnest($1::bigint[], $2::bigint[]) AS t(min_tile, max_tile)
+					ON n.tile BETWEEN t.min_tile AND t.max_tile
+				WHERE n.timestamp <= $7
+			), latest AS (
+				SELECT DISTINCT ON (n.node_id) n.node_id, n.visible, n.latitude, n.longitude
+				FROM nodes n
+				WHERE n.node_id IN (SELECT node_id FROM candidates) AND
+					  n.redaction_id IS NULL AND
+					  n.timestamp <= $7
+				ORDER BY n.node_id, n.version DESC
+			)
+			SELECT l.node_id AS id
+			FROM latest l
+			WHERE l.visible = true AND
+				  l.latitude BETWEEN $3 AND $4 AND
+				  l.longitude BETWEEN $5 AND $6
+			LIMIT $8
+		`),
+	); err != nil {
+		return err
+	}
+
+	if _, err := conn.Prepare(
+		stmtHistoricWaysFromNodes,
+		strings.TrimSpace(`
+			WITH latest AS (
+				SELECT DISTINCT ON (w.way_id) w.way_id, w.version, w.visible
+				FROM ways w
+				WHERE w.way_id IN (
+						SELECT DISTINCT wn.way_id
+						FROM way_nodes wn
+						WHERE wn.node_id = ANY($1)
+					  ) AND
+					  w.redaction_id IS NULL AND
+					  w.timestamp <= $2
+				ORDER BY w.way_id, w.version DESC
+			)
+			SELECT l.way_id AS id
+			FROM latest l
+			WHERE l.visible = true AND
+				  EXISTS (
+					SELECT 1
+					FROM way_nodes wn
+					WHERE wn.way_id = l.way_id AND
+						  wn.version = l.version AND
+						  wn.node_id = ANY($1)
+				  )
+		`),
+	); err != nil {
+		return err
+	}
+
+	if _, err := conn.Prepare(
+		stmtHistoricNodesFromWays,
+		strings.TrimSpace(`
+			WITH latest AS (
+				SELECT DISTINCT ON (w.way_id) w.way_id, w.version
+				FROM ways w
+				WHERE w.way_id = ANY($1) AND
+					  w.redaction_id IS NULL AND
+					  w.timestamp <= $2
+				ORDER BY w.way_id, w.version DESC
+			)
+			SELECT DISTINCT wn.node_id AS id
+			FROM way_nodes wn
+			JOIN latest l ON l.way_id = wn.way_id AND l.version = wn.version
+		`),
+	); err != nil {
+		return err
+	}
+
+	if _, err := conn.Prepare(
+		stmtHistoricRelationParents,
+		strings.TrimSpace(`
+			WITH latest AS (
+				SELECT DISTINCT ON (r.relation_id) r.relation_id, r.version, r.visible
+				FROM relations r
+				WHERE r.relation_id IN (
+						SELECT DISTINCT rm.relation_id
+						FROM relation_members rm
+						WHERE rm.member_type = $1 AND
+							  rm.member_id = ANY($2)
+					  ) AND
+					  r.redaction_id IS NULL AND
+					  r.timestamp <= $3
+				ORDER BY r.relation_id, r.version DESC
+			)
+			SELECT l.relation_id AS id
+			FROM latest l
+			WHERE l.visible = true AND
+				  EXISTS (
+					SELECT 1
+					FROM relation_members rm
+					WHERE rm.relation_id = l.relation_id AND
+						  rm.version = l.version AND
+						  rm.member_type = $1 AND
+						  rm.member_id = ANY($2)
+				  )
+		`),
+	); err != nil {
+		return err
+	}
+
+	for stmt, table := range map[string]string{
+		stmtNodeVersionsAt:     "node",
+		stmtWayVersionsAt:      "way",
+		stmtRelationVersionsAt: "relation",
+	} {
+		if _, err := conn.Prepare(
+			stmt,
+			strings.TrimSpace(fmt.Sprintf(`
+				SELECT DISTINCT ON (e.%[1]v_id) e.%[1]v_id AS id, e.version
+				FROM %[1]vs e
+				WHERE e.%[1]v_id = ANY($1) AND
+					  e.redaction_id IS NULL AND
+					  e.timestamp <= $2
+				ORDER BY e.%[1]v_id, e.version DESC
+			`, table)),
+		); err != nil {
+			return err
+		}
+	}
+
+	for stmt, table := range map[string]string{
+		stmtNodeHistoryRange:     "node",
+		stmtWayHistoryRange:      "way",
+		stmtRelationHistoryRange: "relation",
+	} {
+		if _, err := conn.Prepare(
+			stmt,
+			strings.TrimSpace(fmt.Sprintf(`
+				SELECT e.%[1]v_id AS id, e.version
+				FROM %[1]vs e
+				WHERE e.%[1]v_id = $1 AND
+					  e.redaction_id IS NULL AND
+					  e.version BETWEEN $2 AND $3 AND
+					  e.timestamp >= $4
+				ORDER BY e.version
+				LIMIT $5
+			`, table)),
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.Prepare(
+		stmtWayVersionsFromNode,
+		strings.TrimSpace(`
+			SELECT DISTINCT wn.way_id AS id, wn.version
+			FROM way_nodes wn
+			JOIN ways w ON w.way_id = wn.way_id AND w.version = wn.version
+			WHERE wn.node_id = $1 AND
+				  w.redaction_id IS NULL
+			ORDER BY wn.way_id, wn.version
+		`),
+	); err != nil {
+		return err
+	}
+
+	if _, err := conn.Prepare(
+		stmtRelationVersionsFromMember,
+		strings.TrimSpace(`
+			SELECT DISTINCT rm.relation_id AS id, rm.version
+			FROM relation_members rm
+			JOIN relations r ON r.relation_id = rm.relation_id AND r.version = rm.version
+			WHERE rm.member_type = $1 AND
+				  rm.member_id = $2 AND
+				  r.redaction_id IS NULL
+			ORDER BY rm.relation_id, rm.version
+		`),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// initTagStatements prepares statements selecting current elements by tags,
+// a tag matches if its key is one of the keys and its value is one of the values,
+// NULL values match any value. The (k, v) indexes of the tag tables are used,
+// rows aren't sorted or deduplicated, so the scan stops at the limit.
+func initTagStatements(conn *pgx.ConnPool) error {
+	for stmt, table := range map[string]string{
+		stmtNodesWithTag:     "node",
+		stmtWaysWithTag:      "way",
+		stmtRelationsWithTag: "relation",
+	} {
+		if _, err := conn.Prepare(
+			stmt,
+			strings.TrimSpace(fmt.Sprintf(`
+				SELECT t.%[1]v_id AS id
+				FROM current_%[1]v_tags t
+				JOIN current_%[1]vs e ON e.id = t.%[1]v_id
+				WHERE t.k = ANY($1) AND
+					  ($2::text[] IS NULL OR t.v = ANY($2)) AND
+					  e.visible = true
+				LIMIT $3
+			`, table)),
+		); err != nil {
+			return err
+		}
+	}
+
+	for stmt, table := range map[string]string{
+		stmtFilterNodesWithTag:     "node",
+		stmtFilterWaysWithTag:      "way",
+		stmtFilterRelationsWithTag: "relation",
+	} {
+		if _, err := conn.Prepare(
+			stmt,
+			strings.TrimSpace(fmt.Sprintf(`
+				SELECT t.%[1]v_id AS id
+				FROM current_%[1]v_tags t
+				WHERE t.%[1]v_id = ANY($1) AND
+					  t.k = ANY($2) AND
+					  ($3::text[] IS NULL OR t.v = ANY($3))
+			`, table)),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -14,3 +14,49 @@ func arrayToString(arr []int64) string {
 func versionArrayToString(arr [][2]int64) string {
 	return strings.Trim(strings.Replace(fmt.Sprint(arr), " ", ",", -1), "[]")
 }
+
+// queryIDs runs the statement selecting one id column
+func (o *OsmDB) queryIDs(stmt string, args ...interface{}) ([]int64, error) {
+	rows, err := o.pool.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// queryVersions runs the statement selecting id and version columns
+func (o *OsmDB) queryVersions(stmt string, args ...interface{}) ([][2]int64, error) {
+	rows, err := o.pool.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := [][2]int64{}
+	for rows.Next() {
+		var v [2]int64
+		if err := rows.Scan(&v[0], &v[1]); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// sqlLimit returns the LIMIT argument, NULL means no limit
+func sqlLimit(limit int) interface{} {
+	if limit <= 0 {
+		return nil
+	}
+	return limit
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"time"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// Member types of the relation_members table
+const (
+	memberTypeNode     = "Node"
+	memberTypeWay      = "Way"
+	memberTypeRelation = "Relation"
+)
+
+// SelectHistoricalNodesFromBbox selects ids of nodes which were visible
+// in the bbox at the date, at most limit ids are returned
+func (o *OsmDB) SelectHistoricalNodesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+	date time.Time, limit int) ([]int64, error) {
+	minTiles, maxTiles := tileArrays(tiles)
+	return o.queryIDs(stmtHistoricNodesInBbox, minTiles, maxTiles, minLat, maxLat, minLon, maxLon, date.UTC(), limit)
+}
+
+// SelectHistoricalWaysFromNodes selects ids of ways which used the nodes at the date
+func (o *OsmDB) SelectHistoricalWaysFromNodes(ids []int64, date time.Time) ([]int64, error) {
+	return o.queryIDs(stmtHistoricWaysFromNodes, ids, date.UTC())
+}
+
+// SelectHistoricalNodesFromWays selects ids of nodes of the ways at the date
+func (o *OsmDB) SelectHistoricalNodesFromWays(ids []int64, date time.Time) ([]int64, error) {
+	return o.queryIDs(stmtHistoricNodesFromWays, ids, date.UTC())
+}
+
+// SelectHistoricalRelationsFromNodes selects ids of relations which had the nodes as members at the date
+func (o *OsmDB) SelectHistoricalRelationsFromNodes(ids []int64, date time.Time) ([]int64, error) {
+	return o.queryIDs(stmtHistoricRelationParents, memberTypeNode, ids, date.UTC())
+}
+
+// SelectHistoricalRelationsFromWays selects ids of relations which had the ways as members at the date
+func (o *OsmDB) SelectHistoricalRelationsFromWays(ids []int64, date time.Time) ([]int64, error) {
+	return o.queryIDs(stmtHistoricRelationParents, memberTypeWay, ids, date.UTC())
+}
+
+// SelectHistoricalRelationsFromRelations selects ids of relations which had the relations as members at the date
+func (o *OsmDB) SelectHistoricalRelationsFromRelations(ids []int64, date time.Time) ([]int64, error) {
+	return o.queryIDs(stmtHistoricRelationParents, memberTypeRelation, ids, date.UTC())
+}
+
+// SelectNodeVersionsAt selects the latest versions of the nodes at the date
+func (o *OsmDB) SelectNodeVersionsAt(ids []int64, date time.Time) ([][2]int64, error) {
+	return o.queryVersions(stmtNodeVersionsAt, ids, date.UTC())
+}
+
+// SelectWayVersionsAt selects the latest versions of the ways at the date
+func (o *OsmDB) SelectWayVersionsAt(ids []int64, date time.Time) ([][2]int64, error) {
+	return o.queryVersions(stmtWayVersionsAt, ids, date.UTC())
+}
+
+// SelectRelationVersionsAt selects the latest versions of the relations at the date
+func (o *OsmDB) SelectRelationVersionsAt(ids []int64, date time.Time) ([][2]int64, error) {
+	return o.queryVersions(stmtRelationVersionsAt, ids, date.UTC())
+}
+
+// SelectNodeHistoryRange selects versions of the node between from and to
+// created since the date, at most limit versions are returned if limit isn't 0
+func (o *OsmDB) SelectNodeHistoryRange(id, from, to int64, since time.Time, limit int) ([][2]int64, error) {
+	return o.queryVersions(stmtNodeHistoryRange, id, from, to, since.UTC(), sqlLimit(limit))
+}
+
+// SelectWayHistoryRange selects versions of the way between from and to
+// created since the date, at most limit versions are returned if limit isn't 0
+func (o *OsmDB) SelectWayHistoryRange(id, from, to int64, since time.Time, limit int) ([][2]int64, error) {
+	return o.queryVersions(stmtWayHistoryRange, id, from, to, since.UTC(), sqlLimit(limit))
+}
+
+// SelectRelationHistoryRange selects versions of the relation between from and to
+// created since the date, at most limit versions are returned if limit isn't 0
+func (o *OsmDB) SelectRelationHistoryRange(id, from, to int64, since time.Time, limit int) ([][2]int64, error) {
+	return o.queryVersions(stmtRelationHistoryRange, id, from, to, since.UTC(), sqlLimit(limit))
+}
+
+// SelectWayVersionsFromNode selects all versions of ways which referenced the node
+func (o *OsmDB) SelectWayVersionsFromNode(id int64) ([][2]int64, error) {
+	return o.queryVersions(stmtWayVersionsFromNode, id)
+}
+
+// SelectRelationVersionsFromNode selects all versions of relations which had the node as a member
+func (o *OsmDB) SelectRelationVersionsFromNode(id int64) ([][2]int64, error) {
+	return o.queryVersions(stmtRelationVersionsFromMember, memberTypeNode, id)
+}
+
+// SelectRelationVersionsFromWay selects all versions of relations which had the way as a member
+func (o *OsmDB) SelectRelationVersionsFromWay(id int64) ([][2]int64, error) {
+	return o.queryVersions(stmtRelationVersionsFromMember, memberTypeWay, id)
+}
+
+// SelectRelationVersionsFromRelation selects all versions of relations which had the relation as a member
+func (o *OsmDB) SelectRelationVersionsFromRelation(id int64) ([][2]int64, error) {
+	return o.queryVersions(stmtRelationVersionsFromMember, memberTypeRelation, id)
+}
@@ -55,13 +55,26 @@ func (o *OsmDB) IsNodeVisible(id int64) (bool, error) {
 
 // ExtractNodes extract nodes from database by id
 func (o *OsmDB) ExtractNodes(ids []int64) (osm.Nodes, error) {
-	rows, err := o.pool.Query(stmtExtractNodes, ids)
+	nodes := osm.Nodes{}
+	err := o.StreamNodes(ids, func(node *osm.Node) error {
+		nodes = append(nodes, node)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return nodes, nil
+}
+
+// StreamNodes extracts nodes from database by id and passes them to fn one by one
+func (o *OsmDB) StreamNodes(ids []int64, fn func(*osm.Node) error) error {
+	rows, err := o.pool.Query(stmtExtractNodes, ids)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	nodes := osm.Nodes{}
 	for rows.Next() {
 		node := &osm.Node{}
 		if err := rows.Scan(
@@ -76,15 +89,17 @@ func (o *OsmDB) ExtractNodes(ids []int64) (osm.Nodes, error) {
 			&node.Version,
 			&node.Tags,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(node); err != nil {
+			return err
 		}
-		nodes = append(nodes, node)
 	}
 
-	return nodes, nil
+	return rows.Err()
 }
 
-// ExtractHistoricalNodes returns historical nodes by id and version
+// ExtractHistoricalNodes returns historical nodes by id and version, redacted versions are left out
 func (o *OsmDB) ExtractHistoricalNodes(ids [][2]int64) (osm.Nodes, error) {
 	nodeIDs, vers := []int64{}, []int64{}
 	for i := range ids {
@@ -161,9 +176,13 @@ func (o *OsmDB) SelectNodesFromRelations(ids []int64) ([]int64, error) {
 	return nodeIDs, nil
 }
 
-// SelectNodesFromBbox selects nodes id from database by coordinates
-func (o *OsmDB) SelectNodesFromBbox(bbox []int64) ([]int64, error) {
-	rows, err := o.pool.Query(stmtSelectNodesFromBbox, bbox[0], bbox[1], bbox[2], bbox[3], maxNodes+1)
+// SelectNodesFromBbox selects nodes id from database by fixed point coordinates.
+// Tile ranges covering the bbox are used to look up nodes in the tile index
+// before the exact coordinates check.
+func (o *OsmDB) SelectNodesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64) ([]int64, error) {
+	minTiles, maxTiles := tileArrays(tiles)
+
+	rows, err := o.pool.Query(stmtSelectNodesFromBbox, minTiles, maxTiles, minLat, maxLat, minLon, maxLon, maxNodes+1)
 	if err != nil {
 		return nil, err
 	}
@@ -180,3 +199,50 @@ func (o *OsmDB) SelectNodesFromBbox(bbox []int64) ([]int64, error) {
 
 	return nodeIDs, nil
 }
+
+// SelectNodeCoordinatesFromBbox selects ids and fixed point coordinates of visible nodes
+// in the bbox, at most limit nodes are returned
+func (o *OsmDB) SelectNodeCoordinatesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+	limit int) ([][3]int64, error) {
+	minTiles, maxTiles := tileArrays(tiles)
+	return o.queryNodeCoordinates(stmtNodeCoordinatesInBbox, minTiles, maxTiles, minLat, maxLat, minLon, maxLon, limit)
+}
+
+// SelectNodeCoordinatesFromBboxAfter selects nodes in the bbox like SelectNodeCoordinatesFromBbox,
+// the nodes are sorted by id and only ids greater than after are selected, so all nodes
+// can be fetched page by page
+func (o *OsmDB) SelectNodeCoordinatesFromBboxAfter(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+	after int64, limit int) ([][3]int64, error) {
+	minTiles, maxTiles := tileArrays(tiles)
+	return o.queryNodeCoordinates(stmtNodeCoordinatesInBboxAfter, minTiles, maxTiles, minLat, maxLat, minLon, maxLon,
+		after, limit)
+}
+
+// tileArrays returns minima and maxima of the tile ranges as statement arguments
+func tileArrays(tiles []osm.TileRange) (minTiles, maxTiles []int64) {
+	minTiles = make([]int64, len(tiles))
+	maxTiles = make([]int64, len(tiles))
+	for i := range tiles {
+		minTiles[i], maxTiles[i] = int64(tiles[i].Min), int64(tiles[i].Max)
+	}
+	return minTiles, maxTiles
+}
+
+// queryNodeCoordinates runs the statement selecting id, latitude and longitude columns
+func (o *OsmDB) queryNodeCoordinates(stmt string, args ...interface{}) ([][3]int64, error) {
+	rows, err := o.pool.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := [][3]int64{}
+	for rows.Next() {
+		var n [3]int64
+		if err := rows.Scan(&n[0], &n[1], &n[2]); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx"
+	"github.com/osmlab/gomap/config"
+	"github.com/osmlab/gomap/osm"
+)
+
+const (
+	// benchmarkDB is the environment variable with postgres URI of an osm database
+	// for the benchmarks, they are skipped if it isn't set
+	benchmarkDB = "GOMAP_BENCH_DB"
+	// benchmarkBBox is the environment variable with the benchmark bbox
+	// min_lon,min_lat,max_lon,max_lat, central London by default
+	benchmarkBBox = "GOMAP_BENCH_BBOX"
+
+	// stmtBenchmarkNodesInBbox is the lat/lon statement used before the tile index
+	stmtBenchmarkNodesInBbox = "benchmark_visible_node_in_bbox"
+)
+
+// benchmarkOsmDB connects to the benchmark database
+func benchmarkOsmDB(b *testing.B) *OsmDB {
+	uri := os.Getenv(benchmarkDB)
+	if uri == "" {
+		b.Skipf("%v isn't set, the benchmark needs an osm database", benchmarkDB)
+	}
+	c, err := pgx.ParseURI(uri)
+	if err != nil {
+		b.Fatalf("uri error: %v", err)
+	}
+	o, err := Init(config.DB{Host: c.Host, Port: c.Port, DBName: c.Database, User: c.User, Password: c.Password})
+	if err != nil {
+		b.Fatalf("connect error: %v", err)
+	}
+	return o
+}
+
+// benchmarkBounds returns the benchmark bbox in degrees and fixed point coordinates
+func benchmarkBounds(b *testing.B) (*osm.Bounds, [4]int64) {
+	raw := os.Getenv(benchmarkBBox)
+	if raw == "" {
+		raw = "-0.1,51.5,0,51.52"
+	}
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		b.Fatalf("%v must be min_lon,min_lat,max_lon,max_lat", benchmarkBBox)
+	}
+	var v [4]float64
+	var fixed [4]int64
+	for i := range parts {
+		f, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil {
+			b.Fatalf("%v error: %v", benchmarkBBox, err)
+		}
+		v[i], fixed[i] = f, int64(math.Round(f*1e7))
+	}
+	return &osm.Bounds{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}, fixed
+}
+
+// BenchmarkSelectNodesFromBbox compares the prepared tile range statement of
+// SelectNodesFromBbox with the lat/lon statement used before, both return the same nodes
+func BenchmarkSelectNodesFromBbox(b *testing.B) {
+	o := benchmarkOsmDB(b)
+	bounds, bbox := benchmarkBounds(b)
+	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	tiles := osm.TileRanges(bounds)
+
+	if _, err := o.pool.Prepare(stmtBenchmarkNodesInBbox, `
+		SELECT id
+		FROM current_nodes
+		WHERE latitude BETWEEN $1 AND $2 AND
+			  longitude BETWEEN $3 AND $4 AND
+			  visible = true
+		LIMIT $5`); err != nil {
+		b.Fatalf("prepare error: %v", err)
+	}
+	selectLatLon := func() ([]int64, error) {
+		rows, err := o.pool.Query(stmtBenchmarkNodesInBbox, minLat, maxLat, minLon, maxLon, maxNodes+1)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, rows.Err()
+	}
+	selectTiles := func() ([]int64, error) {
+		return o.SelectNodesFromBbox(tiles, minLon, minLat, maxLon, maxLat)
+	}
+
+	old, err := selectLatLon()
+	if err != nil {
+		b.Fatalf("lat/lon query error: %v", err)
+	}
+	ids, err := selectTiles()
+	if err != nil {
+		b.Fatalf("tile query error: %v", err)
+	}
+	if len(old) > maxNodes {
+		b.Fatalf("the bbox has more than %v nodes, the results can't be compared", maxNodes)
+	}
+	sort.Slice(old, func(i, j int) bool { return old[i] < old[j] })
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) != len(old) {
+		b.Fatalf("the tile query returns %v nodes, the lat/lon query %v", len(ids), len(old))
+	}
+	for i := range ids {
+		if ids[i] != old[i] {
+			b.Fatalf("the tile query returns node %v, the lat/lon query %v", ids[i], old[i])
+		}
+	}
+
+	for _, bc := range []struct {
+		name  string
+		query func() ([]int64, error)
+	}{
+		{"latlon", selectLatLon},
+		{"quadtile", selectTiles},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.query(); err != nil {
+					b.Fatalf("query error: %v", err)
+				}
+			}
+		})
+	}
+}
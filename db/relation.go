@@ -53,13 +53,26 @@ func (o *OsmDB) IsRelationVisible(id int64) (bool, error) {
 
 // ExtractRelations extract relations by id
 func (o *OsmDB) ExtractRelations(ids []int64) (osm.Relations, error) {
-	rows, err := o.pool.Query(stmtExtractRelations, ids)
+	relations := osm.Relations{}
+	err := o.StreamRelations(ids, func(relation *osm.Relation) error {
+		relations = append(relations, relation)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return relations, nil
+}
+
+// StreamRelations extracts relations from database by id and passes them to fn one by one
+func (o *OsmDB) StreamRelations(ids []int64, fn func(*osm.Relation) error) error {
+	rows, err := o.pool.Query(stmtExtractRelations, ids)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	relations := osm.Relations{}
 	for rows.Next() {
 		relation := &osm.Relation{}
 		if err := rows.Scan(
@@ -73,15 +86,18 @@ func (o *OsmDB) ExtractRelations(ids []int64) (osm.Relations, error) {
 			&relation.Tags,
 			&relation.Members,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(relation); err != nil {
+			return err
 		}
-		relations = append(relations, relation)
 	}
 
-	return relations, err
+	return rows.Err()
 }
 
-// ExtractHistoricalRelations extarct historical relations from database by id and version
+// ExtractHistoricalRelations extarct historical relations from database by id and version,
+// redacted versions are left out
 func (o *OsmDB) ExtractHistoricalRelations(ids [][2]int64) (osm.Relations, error) {
 	relIDs, vers := []int64{}, []int64{}
 	for i := range ids {
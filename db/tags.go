@@ -0,0 +1,43 @@
+package db
+
+// sqlValues returns the tag values argument, NULL means any value
+func sqlValues(values []string) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// SelectNodesWithTag selects ids of visible nodes having a tag with one of the keys
+// and one of the values, any value matches if values are empty.
+// At most limit ids are returned, an id is repeated for every matching tag.
+func (o *OsmDB) SelectNodesWithTag(keys, values []string, limit int) ([]int64, error) {
+	return o.queryIDs(stmtNodesWithTag, keys, sqlValues(values), sqlLimit(limit))
+}
+
+// SelectWaysWithTag selects ids of visible ways by the tag like SelectNodesWithTag
+func (o *OsmDB) SelectWaysWithTag(keys, values []string, limit int) ([]int64, error) {
+	return o.queryIDs(stmtWaysWithTag, keys, sqlValues(values), sqlLimit(limit))
+}
+
+// SelectRelationsWithTag selects ids of visible relations by the tag like SelectNodesWithTag
+func (o *OsmDB) SelectRelationsWithTag(keys, values []string, limit int) ([]int64, error) {
+	return o.queryIDs(stmtRelationsWithTag, keys, sqlValues(values), sqlLimit(limit))
+}
+
+// FilterNodesWithTag returns the nodes of ids which have a tag with one of the keys
+// and one of the values, any value matches if values are empty.
+// An id is repeated for every matching tag.
+func (o *OsmDB) FilterNodesWithTag(ids []int64, keys, values []string) ([]int64, error) {
+	return o.queryIDs(stmtFilterNodesWithTag, ids, keys, sqlValues(values))
+}
+
+// FilterWaysWithTag returns the ways of ids which have the tag like FilterNodesWithTag
+func (o *OsmDB) FilterWaysWithTag(ids []int64, keys, values []string) ([]int64, error) {
+	return o.queryIDs(stmtFilterWaysWithTag, ids, keys, sqlValues(values))
+}
+
+// FilterRelationsWithTag returns the relations of ids which have the tag like FilterNodesWithTag
+func (o *OsmDB) FilterRelationsWithTag(ids []int64, keys, values []string) ([]int64, error) {
+	return o.queryIDs(stmtFilterRelationsWithTag, ids, keys, sqlValues(values))
+}
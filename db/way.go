@@ -53,13 +53,26 @@ func (o *OsmDB) IsWayVisible(id int64) (bool, error) {
 
 // ExtractWays selects ways from database by id
 func (o *OsmDB) ExtractWays(ids []int64) (osm.Ways, error) {
-	rows, err := o.pool.Query(stmtExtractWays, ids)
+	ways := osm.Ways{}
+	err := o.StreamWays(ids, func(way *osm.Way) error {
+		ways = append(ways, way)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	return ways, nil
+}
+
+// StreamWays extracts ways from database by id and passes them to fn one by one
+func (o *OsmDB) StreamWays(ids []int64, fn func(*osm.Way) error) error {
+	rows, err := o.pool.Query(stmtExtractWays, ids)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	ways := osm.Ways{}
 	for rows.Next() {
 		way := &osm.Way{}
 		if err := rows.Scan(
@@ -73,15 +86,17 @@ func (o *OsmDB) ExtractWays(ids []int64) (osm.Ways, error) {
 			&way.Tags,
 			&way.Nodes,
 		); err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(way); err != nil {
+			return err
 		}
-		ways = append(ways, way)
 	}
 
-	return ways, nil
+	return rows.Err()
 }
 
-// ExtractHistoricalWays returns historical ways by id and version
+// ExtractHistoricalWays returns historical ways by id and version, redacted versions are left out
 func (o *OsmDB) ExtractHistoricalWays(ids [][2]int64) (osm.Ways, error) {
 	wayIDs, vers := []int64{}, []int64{}
 	for i := range ids {
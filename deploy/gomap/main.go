@@ -30,9 +30,11 @@ func init() {
 
 func main() {
 	config := &config.Config{
-		Port: os.Getenv("PORT"),
+		Port:        os.Getenv("PORT"),
+		MinTileZoom: 12,
+		MaxMapArea:  0.25,
 	}
-	g := gomap.New(database)
+	g := gomap.New(database, config)
 	server := server.New(g)
 	router := router.Load(config, server)
 	err := router.Start(":" + config.Port)
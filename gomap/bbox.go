@@ -0,0 +1,107 @@
+package gomap
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// scale is the number of fixed point units in one degree
+const scale = 1e7
+
+var (
+	errBBox = BadRequest(
+		"The parameter bbox is required, and must be of the form min_lon,min_lat,max_lon,max_lat.")
+	errBBoxRange = BadRequest(
+		"The latitudes must be between -90 and 90, longitudes between -180 and 180 and the minima must be less than the maxima.")
+)
+
+// BBox is a bounding box in the database fixed point coordinates,
+// which are degrees multiplied by 1e7
+type BBox struct {
+	MinLon, MinLat, MaxLon, MaxLat int64
+}
+
+// ParseBBox parses min_lon,min_lat,max_lon,max_lat bbox
+// and checks that it's a valid area
+func ParseBBox(raw string) (BBox, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return BBox{}, errBBox
+	}
+
+	var coordinates [4]int64
+	for i := range parts {
+		v, err := parseFixedPoint(strings.TrimSpace(parts[i]))
+		if err != nil {
+			return BBox{}, errBBox
+		}
+		coordinates[i] = v
+	}
+
+	b := BBox{MinLon: coordinates[0], MinLat: coordinates[1], MaxLon: coordinates[2], MaxLat: coordinates[3]}
+	if b.MinLon < -180*scale || b.MaxLon > 180*scale || b.MinLat < -90*scale || b.MaxLat > 90*scale ||
+		b.MinLon >= b.MaxLon || b.MinLat >= b.MaxLat {
+		return BBox{}, errBBoxRange
+	}
+	return b, nil
+}
+
+// parseFixedPoint parses decimal degrees into fixed point exactly,
+// digits after the 7th decimal place are rounded half away from zero
+func parseFixedPoint(s string) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i != -1 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+	if len(integer) == 0 && len(fraction) == 0 {
+		return 0, strconv.ErrSyntax
+	}
+	for _, part := range []string{integer, fraction} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, strconv.ErrSyntax
+			}
+		}
+	}
+	if len(integer) > 3 {
+		return 0, strconv.ErrRange
+	}
+
+	round := len(fraction) > 7 && fraction[7] >= '5'
+	for len(fraction) < 7 {
+		fraction += "0"
+	}
+	v, err := strconv.ParseInt(integer+fraction[:7], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if round {
+		v++
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// Area returns the area of the bbox in square degrees
+func (b BBox) Area() float64 {
+	return float64(b.MaxLon-b.MinLon) / scale * float64(b.MaxLat-b.MinLat) / scale
+}
+
+// Bounds returns the bbox in degrees
+func (b BBox) Bounds() *osm.Bounds {
+	return &osm.Bounds{
+		MinLat: float64(b.MinLat) / scale,
+		MinLon: float64(b.MinLon) / scale,
+		MaxLat: float64(b.MaxLat) / scale,
+		MaxLon: float64(b.MaxLon) / scale,
+	}
+}
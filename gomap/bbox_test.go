@@ -0,0 +1,45 @@
+package gomap
+
+import "testing"
+
+func TestParseBBox(t *testing.T) {
+	cases := []struct {
+		raw  string
+		bbox BBox
+		err  error
+	}{
+		{raw: "-0.1234567,51,0.1,51.5", bbox: BBox{MinLon: -1234567, MinLat: 510000000, MaxLon: 1000000, MaxLat: 515000000}},
+		{raw: "-179.9,-90,180,90", bbox: BBox{MinLon: -1799000000, MinLat: -900000000, MaxLon: 1800000000, MaxLat: 900000000}},
+		{raw: " +1 , .5 ,2., 1", bbox: BBox{MinLon: 10000000, MinLat: 5000000, MaxLon: 20000000, MaxLat: 10000000}},
+		{raw: "0.12345675,0.12345674,1,1", bbox: BBox{MinLon: 1234568, MinLat: 1234567, MaxLon: 10000000, MaxLat: 10000000}},
+		{raw: "-0.00000005,-1,0.99999995,1", bbox: BBox{MinLon: -1, MinLat: -10000000, MaxLon: 10000000, MaxLat: 10000000}},
+		{raw: "-+1,0,2,1", err: errBBox},
+		{raw: "--1,0,2,1", err: errBBox},
+		{raw: "+-1,0,2,1", err: errBBox},
+		{raw: "1e1,0,20,1", err: errBBox},
+		{raw: "-,0,2,1", err: errBBox},
+		{raw: ".,0,2,1", err: errBBox},
+		{raw: "0,0,1", err: errBBox},
+		{raw: "0,0,1,1,1", err: errBBox},
+		{raw: "", err: errBBox},
+		{raw: "1000,0,2000,1", err: errBBox},
+		{raw: "1,0,1,1", err: errBBoxRange},
+		{raw: "2,0,1,1", err: errBBoxRange},
+		{raw: "0,1,1,1", err: errBBoxRange},
+		{raw: "-180.0000001,0,1,1", err: errBBoxRange},
+		{raw: "0,0,180.0000001,1", err: errBBoxRange},
+		{raw: "0,-90.1,1,1", err: errBBoxRange},
+		{raw: "0,0,1,91", err: errBBoxRange},
+	}
+
+	for _, tc := range cases {
+		b, err := ParseBBox(tc.raw)
+		if err != tc.err {
+			t.Errorf("incorrect error of %q: %v, expected %v", tc.raw, err, tc.err)
+			continue
+		}
+		if b != tc.bbox {
+			t.Errorf("incorrect bbox of %q: %+v, expected %+v", tc.raw, b, tc.bbox)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// BlameHandler returns data for /api/0.6/:type/:id/blame request
+func (g *Gomap) BlameHandler(elementType string, id int64) (*osm.Blame, error) {
+	var blame *osm.Blame
+	var visible bool
+	switch elementType {
+	case "node":
+		history, _, err := g.NodeHistoryHandler(id, HistoryRange{})
+		if err != nil {
+			return nil, err
+		}
+		blame = osm.NodeBlame(history.Nodes)
+		visible = findNodeVersion(history.Nodes, int64(blame.Version)).Visible
+	case "way":
+		history, _, err := g.WayHistoryHandler(id, HistoryRange{})
+		if err != nil {
+			return nil, err
+		}
+		blame = osm.WayBlame(history.Ways)
+		visible = findWayVersion(history.Ways, int64(blame.Version)).Visible
+	case "relation":
+		history, _, err := g.RelationHistoryHandler(id, HistoryRange{})
+		if err != nil {
+			return nil, err
+		}
+		blame = osm.RelationBlame(history.Relations)
+		visible = findRelationVersion(history.Relations, int64(blame.Version)).Visible
+	default:
+		return nil, BadRequest("Unknown element type %q", elementType)
+	}
+
+	if !visible {
+		return nil, ErrElementDeleted
+	}
+	return blame, nil
+}
@@ -0,0 +1,98 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// DiffHandler returns data for /api/0.6/:type/:id/diff?from=...&to=... request.
+// The latest version is used if to is 0 and the previous version of to if from is 0.
+func (g *Gomap) DiffHandler(elementType string, id, from, to int64) (*osm.Diff, error) {
+	var history *osm.OSM
+	var err error
+	switch elementType {
+	case "node":
+		history, _, err = g.NodeHistoryHandler(id, HistoryRange{})
+	case "way":
+		history, _, err = g.WayHistoryHandler(id, HistoryRange{})
+	case "relation":
+		history, _, err = g.RelationHistoryHandler(id, HistoryRange{})
+	default:
+		return nil, BadRequest("Unknown element type %q", elementType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var latest int64
+	for _, n := range history.Nodes {
+		latest = maxVersion(latest, n.Version)
+	}
+	for _, w := range history.Ways {
+		latest = maxVersion(latest, w.Version)
+	}
+	for _, r := range history.Relations {
+		latest = maxVersion(latest, r.Version)
+	}
+	if to == 0 {
+		to = latest
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 || from >= to {
+		return nil, BadRequest("Version from must be lower than version to, got %v and %v", from, to)
+	}
+
+	switch elementType {
+	case "node":
+		a, b := findNodeVersion(history.Nodes, from), findNodeVersion(history.Nodes, to)
+		if a == nil || b == nil {
+			return nil, ErrElementNotFound
+		}
+		return osm.NodeDiff(a, b), nil
+	case "way":
+		a, b := findWayVersion(history.Ways, from), findWayVersion(history.Ways, to)
+		if a == nil || b == nil {
+			return nil, ErrElementNotFound
+		}
+		return osm.WayDiff(a, b), nil
+	default:
+		a, b := findRelationVersion(history.Relations, from), findRelationVersion(history.Relations, to)
+		if a == nil || b == nil {
+			return nil, ErrElementNotFound
+		}
+		return osm.RelationDiff(a, b), nil
+	}
+}
+
+func maxVersion(v int64, version int) int64 {
+	if int64(version) > v {
+		return int64(version)
+	}
+	return v
+}
+
+func findNodeVersion(nodes osm.Nodes, version int64) *osm.Node {
+	for _, n := range nodes {
+		if int64(n.Version) == version {
+			return n
+		}
+	}
+	return nil
+}
+
+func findWayVersion(ways osm.Ways, version int64) *osm.Way {
+	for _, w := range ways {
+		if int64(w.Version) == version {
+			return w
+		}
+	}
+	return nil
+}
+
+func findRelationVersion(relations osm.Relations, version int64) *osm.Relation {
+	for _, r := range relations {
+		if int64(r.Version) == version {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// ElementIDs are current and historic ids of elements of one type
+type ElementIDs struct {
+	IDs     []int64
+	HistIDs [][2]int64
+}
+
+// isEmpty returns true if there are no ids
+func (e ElementIDs) isEmpty() bool {
+	return len(e.IDs) == 0 && len(e.HistIDs) == 0
+}
+
+// ElementsHandler is used to get data for /api/0.6/elements?nodes=...&ways=...&relations=... request
+func (g *Gomap) ElementsHandler(nodes, ways, relations ElementIDs) (*osm.OSM, error) {
+	resp := osm.New()
+	if !nodes.isEmpty() {
+		o, err := g.NodesHandler(nodes.IDs, nodes.HistIDs)
+		if err != nil {
+			return nil, err
+		}
+		resp.Nodes = o.Nodes
+	}
+	if !ways.isEmpty() {
+		o, err := g.WaysHandler(ways.IDs, ways.HistIDs)
+		if err != nil {
+			return nil, err
+		}
+		resp.Ways = o.Ways
+	}
+	if !relations.isEmpty() {
+		o, err := g.RelationsHandler(relations.IDs, relations.HistIDs)
+		if err != nil {
+			return nil, err
+		}
+		resp.Relations = o.Relations
+	}
+	return resp, nil
+}
@@ -0,0 +1,36 @@
+package gomap
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error is an error which is returned to the client
+// with its http status and message
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError returns new Error with formatted message
+func NewError(status int, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// BadRequest returns new Error with 400 status
+func BadRequest(format string, args ...interface{}) *Error {
+	return NewError(http.StatusBadRequest, format, args...)
+}
+
+var (
+	// ErrElementNotFound determines that element doesn't exist
+	ErrElementNotFound = NewError(http.StatusNotFound, "The requested element was not found")
+	// ErrElementDeleted determines that element is deleted
+	ErrElementDeleted = NewError(http.StatusGone, "The requested element has been deleted")
+	// ErrZoomTooLow determines that tile zoom is lower than configured minimum
+	ErrZoomTooLow = BadRequest("The requested zoom is too low")
+)
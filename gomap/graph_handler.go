@@ -0,0 +1,244 @@
+package gomap
+
+import (
+	"strconv"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// maxGraphVertices is the largest number of elements of membership graph
+const maxGraphVertices = 10000
+
+// graphSelector selects relations of the membership graph, it's implemented by the database
+type graphSelector interface {
+	ExtractRelations(ids []int64) (osm.Relations, error)
+	SelectRelationsFromNodes(ids []int64) ([]int64, error)
+	SelectRelationsFromWays(ids []int64) ([]int64, error)
+	SelectRelationsFromRelations(ids []int64) ([]int64, error)
+}
+
+// RelationGraphHandler returns data for /api/0.6/relation/:id/graph request:
+// all ancestors of the relation, its members and all nested relations.
+// Node and way members are included only for the requested relation.
+func (g *Gomap) RelationGraphHandler(id int64) (*osm.Graph, error) {
+	if err := g.checkCurrent("relation", id); err != nil {
+		return nil, err
+	}
+
+	b := newGraphBuilder(g.db, maxGraphVertices)
+	if err := b.descendants(id); err != nil {
+		return nil, err
+	}
+	if err := b.ancestors("relation", id); err != nil {
+		return nil, err
+	}
+	return b.graph, nil
+}
+
+// AncestorsHandler returns data for /api/0.6/:type/:id/ancestors request:
+// parents of the element, their parents and so on
+func (g *Gomap) AncestorsHandler(elementType string, id int64) (*osm.Graph, error) {
+	if err := g.checkCurrent(elementType, id); err != nil {
+		return nil, err
+	}
+
+	b := newGraphBuilder(g.db, maxGraphVertices)
+	if err := b.addVertex(elementType, id, ""); err != nil {
+		return nil, err
+	}
+	if err := b.ancestors(elementType, id); err != nil {
+		return nil, err
+	}
+	return b.graph, nil
+}
+
+// checkCurrent checks that the current element exists and isn't deleted
+func (g *Gomap) checkCurrent(elementType string, id int64) error {
+	var ids []int64
+	var isVisible bool
+	var err error
+	switch elementType {
+	case "node":
+		if ids, err = g.db.SelectNodes(id); err == nil && len(ids) != 0 {
+			isVisible, err = g.db.IsNodeVisible(id)
+		}
+	case "way":
+		if ids, err = g.db.SelectWays(id); err == nil && len(ids) != 0 {
+			isVisible, err = g.db.IsWayVisible(id)
+		}
+	case "relation":
+		if ids, err = g.db.SelectRelations(id); err == nil && len(ids) != 0 {
+			isVisible, err = g.db.IsRelationVisible(id)
+		}
+	default:
+		return BadRequest("Unknown element type %q", elementType)
+	}
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrElementNotFound
+	}
+	if !isVisible {
+		return ErrElementDeleted
+	}
+	return nil
+}
+
+// graphBuilder collects vertices and edges of the membership graph,
+// every relation is expanded once, so membership cycles end the walk
+type graphBuilder struct {
+	s        graphSelector
+	limit    int
+	graph    *osm.Graph
+	vertices map[string]*osm.GraphVertex
+	edges    map[string]bool
+}
+
+func newGraphBuilder(s graphSelector, limit int) *graphBuilder {
+	return &graphBuilder{
+		s:        s,
+		limit:    limit,
+		graph:    osm.NewGraph(),
+		vertices: map[string]*osm.GraphVertex{},
+		edges:    map[string]bool{},
+	}
+}
+
+// elementKey returns the key of the element, like relation/123
+func elementKey(elementType string, id int64) string {
+	return elementType + "/" + strconv.FormatInt(id, 10)
+}
+
+// addVertex adds the element to the graph, the name is set if it's known
+func (b *graphBuilder) addVertex(elementType string, id int64, name string) error {
+	key := elementKey(elementType, id)
+	if v, ok := b.vertices[key]; ok {
+		if len(name) != 0 {
+			v.Name = name
+		}
+		return nil
+	}
+	if len(b.vertices) >= b.limit {
+		return BadRequest("The membership graph has too many elements (limit is %v)", b.limit)
+	}
+	v := &osm.GraphVertex{Type: elementType, ID: id, Name: name}
+	b.vertices[key] = v
+	b.graph.Vertices = append(b.graph.Vertices, v)
+	return nil
+}
+
+// addRelation adds the relation vertex with its name
+func (b *graphBuilder) addRelation(r *osm.Relation) error {
+	var name string
+	for _, t := range r.Tags {
+		if t.K == "name" {
+			name = t.V
+		}
+	}
+	return b.addVertex("relation", r.ID, name)
+}
+
+// addEdge adds the membership with the member vertex
+func (b *graphBuilder) addEdge(r *osm.Relation, m osm.Member) error {
+	if err := b.addVertex(m.Type, m.Ref, ""); err != nil {
+		return err
+	}
+	key := strconv.FormatInt(r.ID, 10) + ">" + elementKey(m.Type, m.Ref) + ">" + m.Role
+	if !b.edges[key] {
+		b.edges[key] = true
+		b.graph.Edges = append(b.graph.Edges, &osm.GraphEdge{
+			Relation: r.ID, MemberType: m.Type, Member: m.Ref, Role: m.Role,
+		})
+	}
+	return nil
+}
+
+// ancestors adds all relations which contain the element directly or through other relations
+func (b *graphBuilder) ancestors(elementType string, id int64) error {
+	frontier := map[string]bool{elementKey(elementType, id): true}
+	var parents []int64
+	var err error
+	switch elementType {
+	case "node":
+		parents, err = b.s.SelectRelationsFromNodes([]int64{id})
+	case "way":
+		parents, err = b.s.SelectRelationsFromWays([]int64{id})
+	default:
+		parents, err = b.s.SelectRelationsFromRelations([]int64{id})
+	}
+	if err != nil {
+		return err
+	}
+
+	expanded := map[int64]bool{}
+	if elementType == "relation" {
+		expanded[id] = true
+	}
+	for len(parents) != 0 {
+		relations, err := b.s.ExtractRelations(uniqueIDs(parents))
+		if err != nil {
+			return err
+		}
+
+		next := map[string]bool{}
+		var nextIDs []int64
+		for _, r := range relations {
+			if err := b.addRelation(r); err != nil {
+				return err
+			}
+			for _, m := range r.Members {
+				if frontier[elementKey(m.Type, m.Ref)] {
+					if err := b.addEdge(r, m); err != nil {
+						return err
+					}
+				}
+			}
+			if !expanded[r.ID] {
+				expanded[r.ID] = true
+				next[elementKey("relation", r.ID)] = true
+				nextIDs = append(nextIDs, r.ID)
+			}
+		}
+		if len(nextIDs) == 0 {
+			return nil
+		}
+		frontier = next
+		if parents, err = b.s.SelectRelationsFromRelations(nextIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// descendants adds the relation with its members and all nested relations with their relation members
+func (b *graphBuilder) descendants(id int64) error {
+	expanded := map[int64]bool{id: true}
+	frontier := []int64{id}
+	for len(frontier) != 0 {
+		relations, err := b.s.ExtractRelations(frontier)
+		if err != nil {
+			return err
+		}
+
+		frontier = nil
+		for _, r := range relations {
+			if err := b.addRelation(r); err != nil {
+				return err
+			}
+			for _, m := range r.Members {
+				if m.Type != "relation" && r.ID != id {
+					continue
+				}
+				if err := b.addEdge(r, m); err != nil {
+					return err
+				}
+				if m.Type == "relation" && !expanded[m.Ref] {
+					expanded[m.Ref] = true
+					frontier = append(frontier, m.Ref)
+				}
+			}
+		}
+	}
+	return nil
+}
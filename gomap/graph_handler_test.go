@@ -0,0 +1,53 @@
+package gomap
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+func graphEdges(g *osm.Graph) []string {
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, fmt.Sprintf("%v>%v%v:%v", e.Relation, e.MemberType[:1], e.Member, e.Role))
+	}
+	return edges
+}
+
+func TestGraphAncestors(t *testing.T) {
+	s := fixtureSelector{loadFixture(t, "testdata/relations.osm")}
+
+	b := newGraphBuilder(s, maxGraphVertices)
+	if err := b.ancestors("node", 3); err != nil {
+		t.Fatalf("ancestors error: %v", err)
+	}
+	// the cycle of relations 1, 2 and 3 is walked once
+	expected := []string{"3>n3:platform", "2>r3:stop", "1>r2:", "3>r1:"}
+	if edges := graphEdges(b.graph); !reflect.DeepEqual(edges, expected) {
+		t.Errorf("incorrect edges %v, expected %v", edges, expected)
+	}
+	if len(b.graph.Vertices) != 4 {
+		t.Errorf("incorrect vertices: %v", len(b.graph.Vertices))
+	}
+}
+
+func TestGraphDescendants(t *testing.T) {
+	s := fixtureSelector{loadFixture(t, "testdata/relations.osm")}
+
+	b := newGraphBuilder(s, maxGraphVertices)
+	if err := b.descendants(2); err != nil {
+		t.Fatalf("descendants error: %v", err)
+	}
+	// node members of nested relations aren't included
+	expected := []string{"2>w10:", "2>r3:stop", "3>r1:", "3>r4:", "1>r2:"}
+	if edges := graphEdges(b.graph); !reflect.DeepEqual(edges, expected) {
+		t.Errorf("incorrect edges %v, expected %v", edges, expected)
+	}
+
+	b = newGraphBuilder(s, 3)
+	if err := b.descendants(2); err == nil {
+		t.Errorf("limit isn't checked")
+	}
+}
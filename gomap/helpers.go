@@ -0,0 +1,215 @@
+package gomap
+
+import (
+	"math"
+	"time"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// stripDeleted removes coordinates, tags, nodes and members of deleted
+// elements, osm.org returns only metadata of deleted versions
+func stripDeleted(o *osm.OSM) *osm.OSM {
+	for _, n := range o.Nodes {
+		if !n.Visible {
+			n.Lat, n.Lon, n.Tags = nil, nil, nil
+		}
+	}
+	for _, w := range o.Ways {
+		if !w.Visible {
+			w.Nodes, w.Tags = nil, nil
+		}
+	}
+	for _, r := range o.Relations {
+		if !r.Visible {
+			r.Members, r.Tags = nil, nil
+		}
+	}
+	return o
+}
+
+// maxHistoryVersions is the largest number of versions returned by bulk history requests
+const maxHistoryVersions = 10000
+
+// checkHistoryVersions checks that every element has history and
+// the number of versions is within the limit
+func checkHistoryVersions(ids []int64, versions [][2]int64) error {
+	if len(versions) > maxHistoryVersions {
+		return BadRequest("You requested too many versions (limit is %v), request fewer elements", maxHistoryVersions)
+	}
+	found := map[int64]bool{}
+	for _, v := range versions {
+		found[v[0]] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return ErrElementNotFound
+		}
+	}
+	return nil
+}
+
+// HistoryRange selects a page of element history, zero values don't limit it
+type HistoryRange struct {
+	FromVersion int64
+	ToVersion   int64
+	Since       time.Time
+	Limit       int
+}
+
+// historySelector selects versions of an element history in the range
+type historySelector func(id, from, to int64, since time.Time, limit int) ([][2]int64, error)
+
+// selectHistoryPage selects versions of the element history in the range,
+// the version starting the next page is returned if the limit is reached
+func selectHistoryPage(id int64, r HistoryRange, selectRange historySelector,
+	selectAll func(ids ...int64) ([][2]int64, error)) ([][2]int64, int64, error) {
+	to := r.ToVersion
+	if to == 0 {
+		to = math.MaxInt64
+	}
+	limit := r.Limit
+	if limit > 0 {
+		// one more version tells if there is a next page
+		limit++
+	}
+
+	versions, err := selectRange(id, r.FromVersion, to, r.Since, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(versions) == 0 {
+		// the range may be empty, but the element has to exist
+		all, err := selectAll(id)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(all) == 0 {
+			return nil, 0, ErrElementNotFound
+		}
+		return versions, 0, nil
+	}
+
+	var next int64
+	if r.Limit > 0 && len(versions) > r.Limit {
+		next = versions[r.Limit][1]
+		versions = versions[:r.Limit]
+	}
+	return versions, next, nil
+}
+
+// versionDate returns the date of the element version, timestamps have
+// second precision so the whole second is included
+func versionDate(t osm.Time) time.Time {
+	return time.Time(t).Truncate(time.Second).Add(time.Second - time.Nanosecond)
+}
+
+// versionSelector selects the versions of elements at a date, it's implemented by the database
+type versionSelector interface {
+	SelectNodeVersionsAt(ids []int64, date time.Time) ([][2]int64, error)
+	SelectWayVersionsAt(ids []int64, date time.Time) ([][2]int64, error)
+	SelectRelationVersionsAt(ids []int64, date time.Time) ([][2]int64, error)
+	ExtractHistoricalNodes(ids [][2]int64) (osm.Nodes, error)
+	ExtractHistoricalWays(ids [][2]int64) (osm.Ways, error)
+	ExtractHistoricalRelations(ids [][2]int64) (osm.Relations, error)
+}
+
+// elementsAt returns the latest visible versions of the elements at the date
+func elementsAt(s versionSelector, nodeIDs, wayIDs, relationIDs []int64, date time.Time) (*osm.OSM, error) {
+	resp := osm.New()
+	if len(nodeIDs) != 0 {
+		versions, err := s.SelectNodeVersionsAt(nodeIDs, date)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := s.ExtractHistoricalNodes(versions)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			if n.Visible {
+				resp.Nodes = append(resp.Nodes, n)
+			}
+		}
+	}
+	if len(wayIDs) != 0 {
+		versions, err := s.SelectWayVersionsAt(wayIDs, date)
+		if err != nil {
+			return nil, err
+		}
+		ways, err := s.ExtractHistoricalWays(versions)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range ways {
+			if w.Visible {
+				resp.Ways = append(resp.Ways, w)
+			}
+		}
+	}
+	if len(relationIDs) != 0 {
+		versions, err := s.SelectRelationVersionsAt(relationIDs, date)
+		if err != nil {
+			return nil, err
+		}
+		relations, err := s.ExtractHistoricalRelations(versions)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range relations {
+			if r.Visible {
+				resp.Relations = append(resp.Relations, r)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// wayFullAt writes the way with its nodes as they were at the date
+func (g *Gomap) wayFullAt(way *osm.Way, date time.Time, w osm.Writer) error {
+	var nodeIDs []int64
+	for _, n := range way.Nodes {
+		nodeIDs = append(nodeIDs, n.ID)
+	}
+	resp, err := elementsAt(g.db, uniqueIDs(nodeIDs), nil, nil, date)
+	if err != nil {
+		return err
+	}
+	resp.Ways = osm.Ways{way}
+	return resp.Stream(w)
+}
+
+// relationFullAt writes the relation with its members and nodes of member
+// ways as they were at the date
+func (g *Gomap) relationFullAt(r *osm.Relation, date time.Time, w osm.Writer) error {
+	var nodeIDs, wayIDs, relationIDs []int64
+	for _, m := range r.Members {
+		switch m.Type {
+		case "node":
+			nodeIDs = append(nodeIDs, m.Ref)
+		case "way":
+			wayIDs = append(wayIDs, m.Ref)
+		case "relation":
+			if m.Ref != r.ID {
+				relationIDs = append(relationIDs, m.Ref)
+			}
+		}
+	}
+
+	resp, err := elementsAt(g.db, nil, uniqueIDs(wayIDs), uniqueIDs(relationIDs), date)
+	if err != nil {
+		return err
+	}
+	for _, way := range resp.Ways {
+		for _, n := range way.Nodes {
+			nodeIDs = append(nodeIDs, n.ID)
+		}
+	}
+	nodes, err := elementsAt(g.db, uniqueIDs(nodeIDs), nil, nil, date)
+	if err != nil {
+		return err
+	}
+	resp.Nodes = nodes.Nodes
+	resp.Relations = append(osm.Relations{r}, resp.Relations...)
+	return resp.Stream(w)
+}
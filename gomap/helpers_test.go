@@ -0,0 +1,198 @@
+package gomap
+
+import (
+	"encoding/xml"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+func TestStripDeleted(t *testing.T) {
+	o := &osm.OSM{}
+	if err := xml.Unmarshal([]byte(`<osm>
+		<node id="1" version="1" visible="true" lat="51.5" lon="-0.1"><tag k="amenity" v="cafe"/></node>
+		<node id="1" version="2" visible="false" lat="51.5" lon="-0.1"><tag k="amenity" v="cafe"/></node>
+		<way id="2" version="1" visible="false"><nd ref="1"/><tag k="highway" v="path"/></way>
+		<relation id="3" version="1" visible="false"><member type="node" ref="1" role=""/><tag k="type" v="route"/></relation>
+	</osm>`), o); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if len(o.Ways[0].Nodes) == 0 || len(o.Relations[0].Members) == 0 || o.Nodes[1].Lat == nil {
+		t.Fatalf("incorrect decoded elements: %+v %+v", o.Ways[0], o.Relations[0])
+	}
+	stripDeleted(o)
+
+	if visible := o.Nodes[0]; visible.Lat == nil || visible.Lon == nil || len(visible.Tags) != 1 {
+		t.Errorf("visible version is stripped: %+v", visible)
+	}
+	if deleted := o.Nodes[1]; deleted.Lat != nil || deleted.Lon != nil || deleted.Tags != nil || deleted.Version != 2 {
+		t.Errorf("incorrect deleted node: %+v", deleted)
+	}
+	if w := o.Ways[0]; w.Nodes != nil || w.Tags != nil {
+		t.Errorf("incorrect deleted way: %+v", w)
+	}
+	if r := o.Relations[0]; r.Members != nil || r.Tags != nil {
+		t.Errorf("incorrect deleted relation: %+v", r)
+	}
+}
+
+func TestVersionDate(t *testing.T) {
+	ts := osm.Time(time.Date(2012, 1, 1, 10, 20, 30, 0, time.UTC))
+	date := versionDate(ts)
+
+	// versions created later in the same second are included, since timestamps have second precision
+	if expected := time.Date(2012, 1, 1, 10, 20, 30, 999999999, time.UTC); !date.Equal(expected) {
+		t.Errorf("incorrect date %v, expected %v", date, expected)
+	}
+	if next := time.Date(2012, 1, 1, 10, 20, 31, 0, time.UTC); !date.Before(next) {
+		t.Errorf("the next second %v is included", next)
+	}
+	if d := versionDate(osm.Time(time.Date(2012, 1, 1, 10, 20, 30, 500, time.UTC))); !d.Equal(date) {
+		t.Errorf("incorrect date of fractional timestamp %v", d)
+	}
+}
+
+func TestCheckHistoryVersions(t *testing.T) {
+	many := make([][2]int64, maxHistoryVersions+1)
+	for i := range many {
+		many[i] = [2]int64{1, int64(i + 1)}
+	}
+
+	cases := []struct {
+		name     string
+		ids      []int64
+		versions [][2]int64
+		status   int
+	}{
+		{name: "all found", ids: []int64{1, 2}, versions: [][2]int64{{1, 1}, {1, 2}, {2, 1}}},
+		{name: "limit", ids: []int64{1}, versions: many[:maxHistoryVersions]},
+		{name: "too many", ids: []int64{1}, versions: many, status: http.StatusBadRequest},
+		{name: "no history", ids: []int64{1, 3}, versions: [][2]int64{{1, 1}}, status: http.StatusNotFound},
+		{name: "empty", ids: []int64{1}, versions: [][2]int64{}, status: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkHistoryVersions(tc.ids, tc.versions)
+			if tc.status == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if e, ok := err.(*Error); !ok || e.Status != tc.status {
+				t.Errorf("incorrect error %v, expected status %v", err, tc.status)
+			}
+		})
+	}
+}
+
+// fakeHistory selects versions of element histories like the database does
+type fakeHistory map[int64][]int64
+
+func (f fakeHistory) selectRange(id, from, to int64, since time.Time, limit int) ([][2]int64, error) {
+	versions := [][2]int64{}
+	for _, v := range f[id] {
+		if v >= from && v <= to && (limit == 0 || len(versions) < limit) {
+			versions = append(versions, [2]int64{id, v})
+		}
+	}
+	return versions, nil
+}
+
+func (f fakeHistory) selectAll(ids ...int64) ([][2]int64, error) {
+	return f.selectRange(ids[0], 0, math.MaxInt64, time.Time{}, 0)
+}
+
+func TestSelectHistoryPage(t *testing.T) {
+	h := fakeHistory{1: {1, 2, 3, 4, 5}}
+
+	cases := []struct {
+		name     string
+		id       int64
+		r        HistoryRange
+		versions []int64
+		next     int64
+		limit    int
+		err      error
+	}{
+		{name: "all", id: 1, versions: []int64{1, 2, 3, 4, 5}},
+		{name: "first page", id: 1, r: HistoryRange{Limit: 2}, versions: []int64{1, 2}, next: 3, limit: 3},
+		{name: "next page", id: 1, r: HistoryRange{FromVersion: 3, Limit: 2}, versions: []int64{3, 4}, next: 5, limit: 3},
+		{name: "last page", id: 1, r: HistoryRange{FromVersion: 5, Limit: 2}, versions: []int64{5}, limit: 3},
+		{name: "exact page", id: 1, r: HistoryRange{Limit: 5}, versions: []int64{1, 2, 3, 4, 5}, limit: 6},
+		{name: "to version", id: 1, r: HistoryRange{FromVersion: 2, ToVersion: 3, Limit: 1}, versions: []int64{2}, next: 3, limit: 2},
+		{name: "empty range", id: 1, r: HistoryRange{FromVersion: 6}, versions: []int64{}},
+		{name: "missing element", id: 2, r: HistoryRange{Limit: 2}, err: ErrElementNotFound, limit: 3},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var limit int
+			selectRange := func(id, from, to int64, since time.Time, l int) ([][2]int64, error) {
+				limit = l
+				return h.selectRange(id, from, to, since, l)
+			}
+			versions, next, err := selectHistoryPage(tc.id, tc.r, selectRange, h.selectAll)
+			if err != tc.err {
+				t.Fatalf("incorrect error: %v, expected %v", err, tc.err)
+			}
+			// one more version is fetched to find the next page
+			if limit != tc.limit {
+				t.Errorf("incorrect limit of the query %v, expected %v", limit, tc.limit)
+			}
+			if err != nil {
+				return
+			}
+
+			numbers := []int64{}
+			for _, v := range versions {
+				numbers = append(numbers, v[1])
+			}
+			if !reflect.DeepEqual(numbers, tc.versions) || next != tc.next {
+				t.Errorf("incorrect page %v next %v, expected %v next %v", numbers, next, tc.versions, tc.next)
+			}
+		})
+	}
+}
+
+func TestElementsAt(t *testing.T) {
+	h := loadHistory(t)
+	date := time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		typ      string
+		id       int64
+		versions [][2]int64
+	}{
+		{name: "unchanged node", typ: "node", id: 1, versions: [][2]int64{{1, 1}}},
+		{name: "moved node", typ: "node", id: 2, versions: [][2]int64{{2, 2}}},
+		{name: "deleted node", typ: "node", id: 3},
+		{name: "node created later", typ: "node", id: 4},
+		{name: "redacted latest node", typ: "node", id: 7, versions: [][2]int64{{7, 1}}},
+		{name: "way", typ: "way", id: 10, versions: [][2]int64{{10, 2}}},
+		{name: "deleted way", typ: "way", id: 11},
+		{name: "way created later", typ: "way", id: 12},
+		{name: "redacted latest way", typ: "way", id: 13, versions: [][2]int64{{13, 1}}},
+		{name: "relation", typ: "relation", id: 20, versions: [][2]int64{{20, 2}}},
+		{name: "deleted relation", typ: "relation", id: 23},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ids := map[string][]int64{tc.typ: {tc.id}}
+			resp, err := elementsAt(h, ids["node"], ids["way"], ids["relation"], date)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if versions := versionsOf(resp)[tc.typ]; !reflect.DeepEqual(versions, tc.versions) {
+				t.Errorf("incorrect versions: %v, expected %v", versions, tc.versions)
+			}
+		})
+	}
+}
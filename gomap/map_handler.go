@@ -1,65 +1,185 @@
 package gomap
 
 import (
+	"time"
+
 	"github.com/osmlab/gomap/osm"
 )
 
-const maxNodes = 50000
+const (
+	maxNodes = 50000
+	// maxHistoricalNodes is lower since queries of the history tables are much slower
+	maxHistoricalNodes = 10000
+)
+
+// errTooManyNodes returns error of map request with too many nodes
+func errTooManyNodes(limit int) error {
+	return BadRequest("You requested too many nodes (limit is %v). Either request a smaller area, or use planet.osm", limit)
+}
+
+// mapSelector selects ids of map elements, it's implemented by the database
+type mapSelector interface {
+	SelectNodesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64) ([]int64, error)
+	SelectWaysFromNodes(ids ...int64) ([]int64, error)
+	SelectNodesFromWays(ids []int64) ([]int64, error)
+	SelectRelationsFromNodes(ids []int64) ([]int64, error)
+	SelectRelationsFromWays(ids []int64) ([]int64, error)
+	SelectRelationsFromRelations(ids []int64) ([]int64, error)
+}
 
 // MapHandler is used to get data for /api/0.6/map?... request
-func (g *Gomap) MapHandler(bbox []int64) (*osm.OSM, error) {
-	nodesFromBbox, err := g.db.SelectNodesFromBbox(bbox)
+func (g *Gomap) MapHandler(bbox BBox, w osm.Writer) error {
+	if err := g.checkMapArea(bbox); err != nil {
+		return err
+	}
+
+	nodeIDs, wayIDs, relationIDs, err := selectMap(g.db, bbox, maxNodes)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if len(nodesFromBbox) == 0 {
-		return nil, ErrElementNotFound
+
+	if err := g.db.StreamNodes(nodeIDs, w.WriteNode); err != nil {
+		return err
 	}
-	if len(nodesFromBbox) > maxNodes {
-		return nil, ErrElementNotFound
+	if err := g.db.StreamWays(wayIDs, w.WriteWay); err != nil {
+		return err
+	}
+	return g.db.StreamRelations(relationIDs, w.WriteRelation)
+}
+
+// HistoricalMapHandler is used to get data for /api/0.6/map?bbox=...&date=... request.
+// The latest visible versions of elements at the date are selected by the same rules
+// as the current map elements.
+func (g *Gomap) HistoricalMapHandler(bbox BBox, date time.Time, w osm.Writer) error {
+	if err := g.checkMapArea(bbox); err != nil {
+		return err
 	}
 
-	waysFromNodes, err := g.db.SelectWaysFromNodes(nodesFromBbox...)
+	s := historicalSelector{db: g.db, date: date}
+	nodeIDs, wayIDs, relationIDs, err := selectMap(s, bbox, maxHistoricalNodes)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	nodesFromWays, err := g.db.SelectNodesFromWays(waysFromNodes)
+
+	resp, err := elementsAt(g.db, nodeIDs, wayIDs, relationIDs, date)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	return resp.Stream(w)
+}
+
+func (g *Gomap) checkMapArea(bbox BBox) error {
+	if max := g.config.MaxMapArea; max != 0 && bbox.Area() > max {
+		return BadRequest("The maximum bbox size is %v, and your request was too large. "+
+			"Either request a smaller area, or use planet.osm", max)
 	}
-	relationsFromWays, err := g.db.SelectRelationsFromWays(waysFromNodes)
+	return nil
+}
+
+// historicalMapSelector selects ids of map elements at a date, it's implemented by the database
+type historicalMapSelector interface {
+	SelectHistoricalNodesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64, date time.Time, limit int) ([]int64, error)
+	SelectHistoricalWaysFromNodes(ids []int64, date time.Time) ([]int64, error)
+	SelectHistoricalNodesFromWays(ids []int64, date time.Time) ([]int64, error)
+	SelectHistoricalRelationsFromNodes(ids []int64, date time.Time) ([]int64, error)
+	SelectHistoricalRelationsFromWays(ids []int64, date time.Time) ([]int64, error)
+	SelectHistoricalRelationsFromRelations(ids []int64, date time.Time) ([]int64, error)
+}
+
+// historicalSelector selects ids of map elements as they were at the date
+type historicalSelector struct {
+	db   historicalMapSelector
+	date time.Time
+}
+
+func (s historicalSelector) SelectNodesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64) ([]int64, error) {
+	return s.db.SelectHistoricalNodesFromBbox(tiles, minLon, minLat, maxLon, maxLat, s.date, maxHistoricalNodes+1)
+}
+
+func (s historicalSelector) SelectWaysFromNodes(ids ...int64) ([]int64, error) {
+	return s.db.SelectHistoricalWaysFromNodes(ids, s.date)
+}
+
+func (s historicalSelector) SelectNodesFromWays(ids []int64) ([]int64, error) {
+	return s.db.SelectHistoricalNodesFromWays(ids, s.date)
+}
+
+func (s historicalSelector) SelectRelationsFromNodes(ids []int64) ([]int64, error) {
+	return s.db.SelectHistoricalRelationsFromNodes(ids, s.date)
+}
+
+func (s historicalSelector) SelectRelationsFromWays(ids []int64) ([]int64, error) {
+	return s.db.SelectHistoricalRelationsFromWays(ids, s.date)
+}
+
+func (s historicalSelector) SelectRelationsFromRelations(ids []int64) ([]int64, error) {
+	return s.db.SelectHistoricalRelationsFromRelations(ids, s.date)
+}
+
+// selectMap selects ids of map elements the same way as cgimap:
+// visible nodes in the bbox, visible ways using these nodes, all nodes
+// of these ways, relations using any of the selected nodes or ways
+// and one level of parent relations of these relations.
+// Error is returned if there are more than limit nodes in the bbox.
+func selectMap(s mapSelector, bbox BBox, limit int) (nodeIDs, wayIDs, relationIDs []int64, err error) {
+	tiles := osm.TileRanges(bbox.Bounds())
+	nodesFromBbox, err := s.SelectNodesFromBbox(tiles, bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+	return completeMap(s, nodesFromBbox, limit)
+}
+
+// completeMap selects ids of map elements of the nodes in the area by the rules of selectMap
+func completeMap(s mapSelector, nodesInArea []int64, limit int) (nodeIDs, wayIDs, relationIDs []int64, err error) {
+	if len(nodesInArea) == 0 {
+		return nil, nil, nil, ErrElementNotFound
+	}
+	if len(nodesInArea) > limit {
+		return nil, nil, nil, errTooManyNodes(limit)
 	}
-	relationsFromNodes, err := g.db.SelectRelationsFromNodes(append(nodesFromBbox, nodesFromWays...))
+
+	wayIDs, err = s.SelectWaysFromNodes(nodesInArea...)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	relationsFromRelations, err := g.db.SelectRelationsFromRelations(append(relationsFromWays, relationsFromNodes...))
+	wayIDs = uniqueIDs(wayIDs)
+	nodesFromWays, err := s.SelectNodesFromWays(wayIDs)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
+	nodeIDs = uniqueIDs(nodesInArea, nodesFromWays)
 
-	nodeIDs := append(nodesFromBbox, nodesFromWays...)
-	wayIDs := append(waysFromNodes)
-	relationIDs := append(relationsFromWays, append(relationsFromRelations, relationsFromNodes...)...)
-
-	nodes, err := g.db.ExtractNodes(nodeIDs)
+	relationsFromWays, err := s.SelectRelationsFromWays(wayIDs)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	ways, err := g.db.ExtractWays(wayIDs)
+	relationsFromNodes, err := s.SelectRelationsFromNodes(nodeIDs)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	relations, err := g.db.ExtractRelations(relationIDs)
+	relationIDs = uniqueIDs(relationsFromWays, relationsFromNodes)
+
+	parentRelations, err := s.SelectRelationsFromRelations(relationIDs)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
+	relationIDs = uniqueIDs(relationIDs, parentRelations)
 
-	resp := osm.New()
-	resp.Nodes = nodes
-	resp.Ways = ways
-	resp.Relations = relations
-	return resp, nil
+	return nodeIDs, wayIDs, relationIDs, nil
+}
+
+// uniqueIDs joins id lists dropping repeated ids, the order of first occurrences is kept
+func uniqueIDs(lists ...[]int64) []int64 {
+	seen := map[int64]bool{}
+	result := []int64{}
+	for _, ids := range lists {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+			}
+		}
+	}
+	return result
 }
@@ -0,0 +1,110 @@
+package gomap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectMap(t *testing.T) {
+	s := fixtureSelector{loadFixture(t, "testdata/map.osm")}
+
+	cases := []struct {
+		name      string
+		bbox      string
+		nodes     []int64
+		ways      []int64
+		relations []int64
+		err       error
+	}{
+		{
+			name:      "fixture",
+			bbox:      "0,0,1,1",
+			nodes:     []int64{1, 2, 3},
+			ways:      []int64{10, 12},
+			relations: []int64{21, 20, 25, 22, 26},
+		},
+		{
+			name:      "outside nodes",
+			bbox:      "1.9,1.9,3.1,3.1",
+			nodes:     []int64{4, 5, 3},
+			ways:      []int64{11},
+			relations: []int64{24, 25, 26},
+		},
+		{name: "empty", bbox: "10,10,11,11", err: ErrElementNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bbox, err := ParseBBox(tc.bbox)
+			if err != nil {
+				t.Fatalf("bbox error: %v", err)
+			}
+
+			nodes, ways, relations, err := selectMap(s, bbox, maxNodes)
+			if err != tc.err {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if !reflect.DeepEqual(nodes, tc.nodes) {
+				t.Errorf("incorrect nodes: %v, expected %v", nodes, tc.nodes)
+			}
+			if !reflect.DeepEqual(ways, tc.ways) {
+				t.Errorf("incorrect ways: %v, expected %v", ways, tc.ways)
+			}
+			if !reflect.DeepEqual(relations, tc.relations) {
+				t.Errorf("incorrect relations: %v, expected %v", relations, tc.relations)
+			}
+		})
+	}
+}
+
+func TestSelectHistoricalMap(t *testing.T) {
+	h := loadHistory(t)
+	bbox, err := ParseBBox("0,0,1,1")
+	if err != nil {
+		t.Fatalf("bbox error: %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		date      time.Time
+		nodes     []int64
+		ways      []int64
+		relations []int64
+		err       error
+	}{
+		{
+			name:      "fixture date",
+			date:      time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC),
+			nodes:     []int64{1, 2, 7, 5},
+			ways:      []int64{10, 13},
+			relations: []int64{20, 22},
+		},
+		{
+			name:      "latest edits",
+			date:      time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+			nodes:     []int64{2, 4, 7},
+			ways:      []int64{12, 13},
+			relations: []int64{21},
+		},
+		{name: "before first edits", date: time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC), err: ErrElementNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, ways, relations, err := selectMap(historicalSelector{db: h, date: tc.date}, bbox, maxHistoricalNodes)
+			if err != tc.err {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if !reflect.DeepEqual(nodes, tc.nodes) {
+				t.Errorf("incorrect nodes: %v, expected %v", nodes, tc.nodes)
+			}
+			if !reflect.DeepEqual(ways, tc.ways) {
+				t.Errorf("incorrect ways: %v, expected %v", ways, tc.ways)
+			}
+			if !reflect.DeepEqual(relations, tc.relations) {
+				t.Errorf("incorrect relations: %v, expected %v", relations, tc.relations)
+			}
+		})
+	}
+}
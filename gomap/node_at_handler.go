@@ -0,0 +1,42 @@
+package gomap
+
+import (
+	"time"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// NodeAtHandler returns data for /api/0.6/node/:id?at=... request
+func (g *Gomap) NodeAtHandler(id int64, date time.Time) (*osm.OSM, error) {
+	node, err := nodeAt(g.db, id, date)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Nodes = osm.Nodes{node}
+	return resp, nil
+}
+
+// nodeAt returns the version of the node which was the latest at the date
+func nodeAt(s versionSelector, id int64, date time.Time) (*osm.Node, error) {
+	versions, err := s.SelectNodeVersionsAt([]int64{id}, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrElementNotFound
+	}
+
+	nodes, err := s.ExtractHistoricalNodes(versions)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, ErrElementNotFound
+	}
+	if !nodes[0].Visible {
+		return nil, ErrElementDeleted
+	}
+	return nodes[0], nil
+}
@@ -0,0 +1,37 @@
+package gomap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNodeAt(t *testing.T) {
+	h := loadHistory(t)
+
+	cases := []struct {
+		name    string
+		id      int64
+		date    time.Time
+		version int
+		err     error
+	}{
+		{name: "latest version", id: 2, date: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC), version: 2},
+		{name: "previous version", id: 2, date: time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC), version: 1},
+		{name: "redacted latest version", id: 7, date: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC), version: 1},
+		{name: "deleted", id: 3, date: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC), err: ErrElementDeleted},
+		{name: "created later", id: 4, date: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC), err: ErrElementNotFound},
+		{name: "missing", id: 99, date: time.Date(2013, 1, 1, 0, 0, 0, 0, time.UTC), err: ErrElementNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := nodeAt(h, tc.id, tc.date)
+			if err != tc.err {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if err == nil && (node.ID != tc.id || node.Version != tc.version) {
+				t.Errorf("incorrect node %v version %v, expected version %v", node.ID, node.Version, tc.version)
+			}
+		})
+	}
+}
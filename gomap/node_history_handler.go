@@ -2,22 +2,25 @@ package gomap
 
 import "github.com/osmlab/gomap/osm"
 
-// NodeHistoryHandler is used to get data for /api/0.6/node/.../history request
-func (g *Gomap) NodeHistoryHandler(id int64) (*osm.OSM, error) {
-	ids, err := g.db.SelectNodesHistory(id)
+// NodeHistoryHandler is used to get data for /api/0.6/node/.../history request.
+// Versions in the range are returned with the first version of the next page,
+// which is 0 if there are no more versions.
+func (g *Gomap) NodeHistoryHandler(id int64, r HistoryRange) (*osm.OSM, int64, error) {
+	ids, next, err := selectHistoryPage(id, r, g.db.SelectNodeHistoryRange, g.db.SelectNodesHistory)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+
+	resp := osm.New()
 	if len(ids) == 0 {
-		return nil, ErrElementNotFound
+		return resp, 0, nil
 	}
 
 	nodes, err := g.db.ExtractHistoricalNodes(ids)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	resp := osm.New()
 	resp.Nodes = nodes
-	return resp, nil
+	return stripDeleted(resp), next, nil
 }
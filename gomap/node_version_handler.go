@@ -17,5 +17,5 @@ func (g *Gomap) NodeVersionHandler(id, version int64) (*osm.OSM, error) {
 
 	resp := osm.New()
 	resp.Nodes = nodes
-	return resp, nil
+	return stripDeleted(resp), nil
 }
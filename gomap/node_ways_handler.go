@@ -2,9 +2,22 @@ package gomap
 
 import "github.com/osmlab/gomap/osm"
 
+// nodeWaysSelector selects current ways of a node, it's implemented by the database
+type nodeWaysSelector interface {
+	SelectNodes(ids ...int64) ([]int64, error)
+	SelectWaysFromNodes(ids ...int64) ([]int64, error)
+	ExtractWays(ids []int64) (osm.Ways, error)
+}
+
 // NodeWaysHandler is used to get data for /api/0.6/node/.../ways request
 func (g *Gomap) NodeWaysHandler(id int64) (*osm.OSM, error) {
-	ids, err := g.db.SelectNodes(id)
+	return nodeWays(g.db, id)
+}
+
+// nodeWays returns visible ways using the node, the response is empty
+// if there are no such ways like on osm.org
+func nodeWays(s nodeWaysSelector, id int64) (*osm.OSM, error) {
+	ids, err := s.SelectNodes(id)
 	if err != nil {
 		return nil, err
 	}
@@ -12,25 +25,41 @@ func (g *Gomap) NodeWaysHandler(id int64) (*osm.OSM, error) {
 		return nil, ErrElementNotFound
 	}
 
-	wayIDs, err := g.db.SelectWaysFromNodes(ids...)
+	wayIDs, err := s.SelectWaysFromNodes(ids...)
 	if err != nil {
 		return nil, err
 	}
-	if len(wayIDs) == 0 {
-		return nil, ErrElementNotFound
+
+	ways, err := s.ExtractWays(wayIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := range wayIDs {
-		isVisible, err := g.db.IsWayVisible(wayIDs[i])
-		if err != nil {
-			return nil, err
-		}
-		if !isVisible {
-			return nil, ErrElementDeleted
-		}
+	resp := osm.New()
+	resp.Ways = ways
+	return resp, nil
+}
+
+// NodeWaysHistoryHandler is used to get data for /api/0.6/node/.../ways?history=true request,
+// all versions of ways which ever referenced the node are returned
+func (g *Gomap) NodeWaysHistoryHandler(id int64) (*osm.OSM, error) {
+	return nodeWaysHistory(g.db, id)
+}
+
+func nodeWaysHistory(s reverseHistorySelector, id int64) (*osm.OSM, error) {
+	if err := checkHistory(s, "node", id); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.SelectWayVersionsFromNode(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) > maxHistoryVersions {
+		return nil, BadRequest("You requested too many versions (limit is %v)", maxHistoryVersions)
 	}
 
-	ways, err := g.db.ExtractWays(ids)
+	ways, err := s.ExtractHistoricalWays(versions)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,74 @@
+package gomap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNodeWays(t *testing.T) {
+	s := fixtureSelector{loadFixture(t, "testdata/map.osm")}
+
+	cases := []struct {
+		name string
+		id   int64
+		ways []int64
+		err  error
+	}{
+		{name: "ways", id: 1, ways: []int64{10, 12}},
+		{name: "shared node", id: 3, ways: []int64{10, 11}},
+		{name: "no ways", id: 5, ways: []int64{}},
+		{name: "deleted node", id: 6, ways: []int64{}},
+		{name: "missing node", id: 99, err: ErrElementNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := nodeWays(s, tc.id)
+			if err != tc.err {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			ways := []int64{}
+			for _, w := range resp.Ways {
+				ways = append(ways, w.ID)
+			}
+			if !reflect.DeepEqual(ways, tc.ways) {
+				t.Errorf("incorrect ways: %v, expected %v", ways, tc.ways)
+			}
+		})
+	}
+}
+
+func TestNodeWaysHistory(t *testing.T) {
+	h := loadHistory(t)
+
+	cases := []struct {
+		name     string
+		id       int64
+		versions [][2]int64
+		err      error
+	}{
+		{name: "node", id: 1, versions: [][2]int64{{10, 1}, {10, 2}}},
+		{name: "removed node", id: 6, versions: [][2]int64{{10, 1}}},
+		{name: "deleted node", id: 3, versions: [][2]int64{{11, 1}}},
+		{name: "node added to way", id: 5, versions: [][2]int64{{10, 2}}},
+		{name: "missing node", id: 99, err: ErrElementNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := nodeWaysHistory(h, tc.id)
+			if err != tc.err {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if versions := versionsOf(resp)["way"]; !reflect.DeepEqual(versions, tc.versions) {
+				t.Errorf("incorrect versions: %v, expected %v", versions, tc.versions)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import "github.com/osmlab/gomap/osm"
 
 // NodesHandler is used to get data or /api/0.6/nodes?nodes=... request
 func (g *Gomap) NodesHandler(ids []int64, histIDs [][2]int64) (*osm.OSM, error) {
+	ids = uniqueIDs(ids)
 	current, err := g.db.ExtractNodes(ids)
 	if err != nil {
 		return nil, err
@@ -21,12 +22,6 @@ func (g *Gomap) NodesHandler(ids []int64, histIDs [][2]int64) (*osm.OSM, error)
 	}
 
 	resp := osm.New()
-	for i := range nodes {
-		if !nodes[i].Visible {
-			nodes[i].Lat = nil
-			nodes[i].Lon = nil
-		}
-		resp.Nodes = append(resp.Nodes, nodes[i])
-	}
-	return resp, nil
+	resp.Nodes = nodes
+	return stripDeleted(resp), nil
 }
@@ -0,0 +1,24 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// NodesHistoryHandler is used to get data for /api/0.6/nodes/history?nodes=... request
+func (g *Gomap) NodesHistoryHandler(ids []int64) (*osm.OSM, error) {
+	ids = uniqueIDs(ids)
+	versions, err := g.db.SelectNodesHistory(ids...)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHistoryVersions(ids, versions); err != nil {
+		return nil, err
+	}
+
+	nodes, err := g.db.ExtractHistoricalNodes(versions)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Nodes = nodes
+	return stripDeleted(resp), nil
+}
@@ -0,0 +1,115 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// ParentRelationsHandler is used to get data for /api/0.6/:type/:id/relations request
+func (g *Gomap) ParentRelationsHandler(elementType string, id int64) (*osm.OSM, error) {
+	var ids, relationIDs []int64
+	var err error
+	switch elementType {
+	case "node":
+		if ids, err = g.db.SelectNodes(id); err == nil && len(ids) != 0 {
+			relationIDs, err = g.db.SelectRelationsFromNodes(ids)
+		}
+	case "way":
+		if ids, err = g.db.SelectWays(id); err == nil && len(ids) != 0 {
+			relationIDs, err = g.db.SelectRelationsFromWays(ids)
+		}
+	case "relation":
+		if ids, err = g.db.SelectRelations(id); err == nil && len(ids) != 0 {
+			relationIDs, err = g.db.SelectRelationsFromRelations(ids)
+		}
+	default:
+		return nil, BadRequest("Unknown element type %q", elementType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrElementNotFound
+	}
+
+	relations, err := g.db.ExtractRelations(relationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Relations = relations
+	return resp, nil
+}
+
+// reverseHistorySelector selects versions of ways and relations which ever referenced
+// an element, it's implemented by the database
+type reverseHistorySelector interface {
+	SelectNodesHistory(ids ...int64) ([][2]int64, error)
+	SelectWaysHistory(ids ...int64) ([][2]int64, error)
+	SelectRelationsHistory(ids ...int64) ([][2]int64, error)
+	SelectWayVersionsFromNode(id int64) ([][2]int64, error)
+	SelectRelationVersionsFromNode(id int64) ([][2]int64, error)
+	SelectRelationVersionsFromWay(id int64) ([][2]int64, error)
+	SelectRelationVersionsFromRelation(id int64) ([][2]int64, error)
+	ExtractHistoricalWays(ids [][2]int64) (osm.Ways, error)
+	ExtractHistoricalRelations(ids [][2]int64) (osm.Relations, error)
+}
+
+// ParentRelationsHistoryHandler is used to get data for /api/0.6/:type/:id/relations?history=true request,
+// all versions of relations which ever had the element as a member are returned
+func (g *Gomap) ParentRelationsHistoryHandler(elementType string, id int64) (*osm.OSM, error) {
+	return parentRelationsHistory(g.db, elementType, id)
+}
+
+func parentRelationsHistory(s reverseHistorySelector, elementType string, id int64) (*osm.OSM, error) {
+	if err := checkHistory(s, elementType, id); err != nil {
+		return nil, err
+	}
+
+	var versions [][2]int64
+	var err error
+	switch elementType {
+	case "node":
+		versions, err = s.SelectRelationVersionsFromNode(id)
+	case "way":
+		versions, err = s.SelectRelationVersionsFromWay(id)
+	default:
+		versions, err = s.SelectRelationVersionsFromRelation(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) > maxHistoryVersions {
+		return nil, BadRequest("You requested too many versions (limit is %v)", maxHistoryVersions)
+	}
+
+	relations, err := s.ExtractHistoricalRelations(versions)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Relations = relations
+	return resp, nil
+}
+
+// checkHistory checks that the element ever existed, it may be deleted now
+func checkHistory(s reverseHistorySelector, elementType string, id int64) error {
+	var versions [][2]int64
+	var err error
+	switch elementType {
+	case "node":
+		versions, err = s.SelectNodesHistory(id)
+	case "way":
+		versions, err = s.SelectWaysHistory(id)
+	case "relation":
+		versions, err = s.SelectRelationsHistory(id)
+	default:
+		return BadRequest("Unknown element type %q", elementType)
+	}
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return ErrElementNotFound
+	}
+	return nil
+}
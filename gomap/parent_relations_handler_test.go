@@ -0,0 +1,44 @@
+package gomap
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParentRelationsHistory(t *testing.T) {
+	h := loadHistory(t)
+
+	cases := []struct {
+		name        string
+		elementType string
+		id          int64
+		versions    [][2]int64
+		status      int
+	}{
+		{name: "node", elementType: "node", id: 1, versions: [][2]int64{{21, 1}}},
+		{name: "way", elementType: "way", id: 10, versions: [][2]int64{{20, 2}}},
+		{name: "relation", elementType: "relation", id: 20, versions: [][2]int64{{22, 1}}},
+		{name: "deleted relation", elementType: "relation", id: 23},
+		{name: "missing way", elementType: "way", id: 99, status: http.StatusNotFound},
+		{name: "unknown type", elementType: "changeset", id: 1, status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := parentRelationsHistory(h, tc.elementType, tc.id)
+			if tc.status != 0 {
+				if e, ok := err.(*Error); !ok || e.Status != tc.status {
+					t.Errorf("incorrect error %v, expected status %v", err, tc.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if versions := versionsOf(resp)["relation"]; !reflect.DeepEqual(versions, tc.versions) {
+				t.Errorf("incorrect versions: %v, expected %v", versions, tc.versions)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package gomap
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+const (
+	// maxPolygonPoints is the largest number of points of the map polygon
+	maxPolygonPoints = 50000
+	// polylinePrecision is the number of encoded polyline units in one degree
+	polylinePrecision = 1e5
+)
+
+var errPolygon = BadRequest(
+	"The parameter poly must be a GeoJSON Polygon or MultiPolygon or an encoded polyline of a closed ring.")
+
+// ParsePolygon parses GeoJSON Polygon, MultiPolygon or Feature with one of them,
+// anything else is decoded as an encoded polyline of one ring
+func ParsePolygon(raw string) ([]osm.Polygon, error) {
+	raw = strings.TrimSpace(raw)
+	var polygons []osm.Polygon
+	if strings.HasPrefix(raw, "{") {
+		var err error
+		if polygons, err = parseGeoJSONPolygon([]byte(raw)); err != nil {
+			return nil, errPolygon
+		}
+	} else {
+		ring, err := decodePolyline(raw)
+		if err != nil {
+			return nil, errPolygon
+		}
+		polygons = []osm.Polygon{{ring}}
+	}
+
+	for i := range polygons {
+		if len(polygons[i]) == 0 {
+			return nil, errPolygon
+		}
+		for j, ring := range polygons[i] {
+			if len(ring) != 0 && ring[0] != ring[len(ring)-1] {
+				ring = append(ring, ring[0])
+				polygons[i][j] = ring
+			}
+			if len(ring) < 4 {
+				return nil, errPolygon
+			}
+			for _, p := range ring {
+				if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+					return nil, errBBoxRange
+				}
+			}
+		}
+	}
+	if len(polygons) == 0 {
+		return nil, errPolygon
+	}
+	return polygons, nil
+}
+
+// checkPolygon checks the number of points and the area of the polygons
+func (g *Gomap) checkPolygon(polygons []osm.Polygon) error {
+	points := 0
+	var area float64
+	for _, p := range polygons {
+		for i, ring := range p {
+			points += len(ring)
+			if i == 0 {
+				area += math.Abs(ring.Area())
+			} else {
+				area -= math.Abs(ring.Area())
+			}
+		}
+	}
+	if points > maxPolygonPoints {
+		return BadRequest("The polygon has too many points (limit is %v)", maxPolygonPoints)
+	}
+	if max := g.config.MaxMapArea; max != 0 && area > max {
+		return BadRequest("The maximum polygon area is %v, and your request was too large. "+
+			"Either request a smaller area, or use planet.osm", max)
+	}
+	return nil
+}
+
+func parseGeoJSONPolygon(data []byte) ([]osm.Polygon, error) {
+	var g struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, err
+	}
+
+	switch g.Type {
+	case "Feature":
+		return parseGeoJSONPolygon(g.Geometry)
+	case "Polygon":
+		var p osm.Polygon
+		err := json.Unmarshal(g.Coordinates, &p)
+		return []osm.Polygon{p}, err
+	case "MultiPolygon":
+		var mp []osm.Polygon
+		err := json.Unmarshal(g.Coordinates, &mp)
+		return mp, err
+	}
+	return nil, errPolygon
+}
+
+// decodePolyline decodes the encoded polyline algorithm format with 5 decimals,
+// see https://developers.google.com/maps/documentation/utilities/polylinealgorithm
+func decodePolyline(s string) (osm.Ring, error) {
+	var ring osm.Ring
+	var lat, lon int64
+	for i := 0; i < len(s); {
+		var deltas [2]int64
+		for k := range deltas {
+			var result int64
+			var shift uint
+			for {
+				if i >= len(s) || s[i] < 63 || shift > 60 {
+					return nil, errPolygon
+				}
+				b := int64(s[i]) - 63
+				i++
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[k] = ^(result >> 1)
+			} else {
+				deltas[k] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		lon += deltas[1]
+		ring = append(ring, osm.Point{float64(lon) / polylinePrecision, float64(lat) / polylinePrecision})
+	}
+	return ring, nil
+}
+
+// polygonBBox returns the bbox of the polygons
+func polygonBBox(polygons []osm.Polygon) BBox {
+	b := BBox{MinLon: math.MaxInt64, MinLat: math.MaxInt64, MaxLon: math.MinInt64, MaxLat: math.MinInt64}
+	for _, p := range polygons {
+		for _, ring := range p {
+			for _, pt := range ring {
+				lon, lat := pt[0]*scale, pt[1]*scale
+				b.MinLon = minInt64(b.MinLon, int64(math.Floor(lon)))
+				b.MinLat = minInt64(b.MinLat, int64(math.Floor(lat)))
+				b.MaxLon = maxInt64(b.MaxLon, int64(math.Ceil(lon)))
+				b.MaxLat = maxInt64(b.MaxLat, int64(math.Ceil(lat)))
+			}
+		}
+	}
+	return b
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// polygonEdge is an edge of a polygon ring
+type polygonEdge struct {
+	a, b osm.Point
+}
+
+// polygonIndex checks if points are inside polygons by the even-odd rule.
+// Edges are put into latitude bands which they span, so a point is
+// checked only against edges of its band.
+type polygonIndex struct {
+	minLon, minLat, maxLon, maxLat, bandHeight float64
+	bands                                      [][]polygonEdge
+}
+
+func newPolygonIndex(polygons []osm.Polygon) *polygonIndex {
+	var edges []polygonEdge
+	idx := &polygonIndex{minLon: math.Inf(1), minLat: math.Inf(1), maxLon: math.Inf(-1), maxLat: math.Inf(-1)}
+	for _, p := range polygons {
+		for _, ring := range p {
+			for i := 0; i+1 < len(ring); i++ {
+				edges = append(edges, polygonEdge{ring[i], ring[i+1]})
+				idx.minLon = math.Min(idx.minLon, ring[i][0])
+				idx.minLat = math.Min(idx.minLat, ring[i][1])
+				idx.maxLon = math.Max(idx.maxLon, ring[i][0])
+				idx.maxLat = math.Max(idx.maxLat, ring[i][1])
+			}
+		}
+	}
+
+	count := len(edges)/8 + 1
+	idx.bands = make([][]polygonEdge, count)
+	idx.bandHeight = (idx.maxLat - idx.minLat) / float64(count)
+	for _, e := range edges {
+		first, last := idx.band(math.Min(e.a[1], e.b[1])), idx.band(math.Max(e.a[1], e.b[1]))
+		for i := first; i <= last; i++ {
+			idx.bands[i] = append(idx.bands[i], e)
+		}
+	}
+	return idx
+}
+
+// band returns the band of the latitude
+func (idx *polygonIndex) band(lat float64) int {
+	if idx.bandHeight == 0 {
+		return 0
+	}
+	i := int((lat - idx.minLat) / idx.bandHeight)
+	if i < 0 {
+		return 0
+	}
+	if i >= len(idx.bands) {
+		return len(idx.bands) - 1
+	}
+	return i
+}
+
+// contains checks that the point is inside the polygons
+func (idx *polygonIndex) contains(lon, lat float64) bool {
+	if lat < idx.minLat || lat > idx.maxLat {
+		return false
+	}
+	inside := false
+	for _, e := range idx.bands[idx.band(lat)] {
+		if (e.a[1] > lat) != (e.b[1] > lat) &&
+			lon < (e.b[0]-e.a[0])*(lat-e.a[1])/(e.b[1]-e.a[1])+e.a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Positions of a rectangle relative to the polygons
+const (
+	rectOutside = iota
+	rectInside
+	rectCrossed
+)
+
+// classify returns whether the rectangle is outside the polygons, inside them
+// or crossed by their edges. Edges touching the rectangle cross it.
+func (idx *polygonIndex) classify(minLon, minLat, maxLon, maxLat float64) int {
+	if maxLon < idx.minLon || minLon > idx.maxLon || maxLat < idx.minLat || minLat > idx.maxLat {
+		return rectOutside
+	}
+	for i, last := idx.band(minLat), idx.band(maxLat); i <= last; i++ {
+		for _, e := range idx.bands[i] {
+			if e.crosses(minLon, minLat, maxLon, maxLat) {
+				return rectCrossed
+			}
+		}
+	}
+	// no edge is inside the rectangle, so it's inside or outside as a whole
+	if idx.contains((minLon+maxLon)/2, (minLat+maxLat)/2) {
+		return rectInside
+	}
+	return rectOutside
+}
+
+// crosses checks that the edge has a common point with the rectangle
+func (e polygonEdge) crosses(minLon, minLat, maxLon, maxLat float64) bool {
+	if math.Max(e.a[0], e.b[0]) < minLon || math.Min(e.a[0], e.b[0]) > maxLon ||
+		math.Max(e.a[1], e.b[1]) < minLat || math.Min(e.a[1], e.b[1]) > maxLat {
+		return false
+	}
+	inside := func(p osm.Point) bool {
+		return p[0] >= minLon && p[0] <= maxLon && p[1] >= minLat && p[1] <= maxLat
+	}
+	if inside(e.a) || inside(e.b) {
+		return true
+	}
+	corners := [5]osm.Point{{minLon, minLat}, {maxLon, minLat}, {maxLon, maxLat}, {minLon, maxLat}, {minLon, minLat}}
+	for i := 0; i < 4; i++ {
+		if segmentsIntersect(e.a, e.b, corners[i], corners[i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentsIntersect checks that segments ab and cd have a common point
+func segmentsIntersect(a, b, c, d osm.Point) bool {
+	d1, d2 := orientation(c, d, a), orientation(c, d, b)
+	d3, d4 := orientation(a, b, c), orientation(a, b, d)
+	if d1*d2 < 0 && d3*d4 < 0 {
+		return true
+	}
+	return d1 == 0 && onSegment(c, d, a) || d2 == 0 && onSegment(c, d, b) ||
+		d3 == 0 && onSegment(a, b, c) || d4 == 0 && onSegment(a, b, d)
+}
+
+// orientation returns the sign of the cross product of ab and ac
+func orientation(a, b, c osm.Point) float64 {
+	v := (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// onSegment checks that the point p collinear with ab is between a and b
+func onSegment(a, b, p osm.Point) bool {
+	return p[0] >= math.Min(a[0], b[0]) && p[0] <= math.Max(a[0], b[0]) &&
+		p[1] >= math.Min(a[1], b[1]) && p[1] <= math.Max(a[1], b[1])
+}
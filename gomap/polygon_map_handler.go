@@ -0,0 +1,184 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+const (
+	// polygonChunkNodes is the largest number of nodes fetched at once for a cell of the polygon bbox
+	polygonChunkNodes = 10000
+	// minPolygonCell is the size of the smallest cell in fixed point units, it's the width
+	// of one quadtile, 360 / 2^16 degrees. Nodes of the smallest cells are fetched page by page.
+	minPolygonCell = 54932
+)
+
+// polygonSelector selects nodes with coordinates, it's implemented by the database
+type polygonSelector interface {
+	SelectNodeCoordinatesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+		limit int) ([][3]int64, error)
+	SelectNodeCoordinatesFromBboxAfter(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+		after int64, limit int) ([][3]int64, error)
+}
+
+// PolygonMapHandler is used to get data for /api/0.6/map?poly=... request.
+// Nodes inside the polygons are completed with ways and relations by the same
+// rules as MapHandler, the node limit is applied to the clipped nodes.
+func (g *Gomap) PolygonMapHandler(polygons []osm.Polygon, w osm.Writer) error {
+	if err := g.checkPolygon(polygons); err != nil {
+		return err
+	}
+
+	bbox := polygonBBox(polygons)
+	nodesInPolygon, err := selectPolygonNodes(g.db, polygons, bbox, maxNodes, polygonChunkNodes)
+	if err != nil {
+		return err
+	}
+
+	nodeIDs, wayIDs, relationIDs, err := completeMap(g.db, nodesInPolygon, maxNodes)
+	if err != nil {
+		return err
+	}
+
+	if bw, ok := w.(osm.BoundsWriter); ok {
+		if err := bw.WriteBounds(bbox.Bounds()); err != nil {
+			return err
+		}
+	}
+	if err := g.db.StreamNodes(nodeIDs, w.WriteNode); err != nil {
+		return err
+	}
+	if err := g.db.StreamWays(wayIDs, w.WriteWay); err != nil {
+		return err
+	}
+	return g.db.StreamRelations(relationIDs, w.WriteRelation)
+}
+
+// RelationMapHandler is used to get data for /api/0.6/map?relation=... request,
+// the outline of the boundary or multipolygon relation is used as the map polygon
+func (g *Gomap) RelationMapHandler(id int64, w osm.Writer) error {
+	if err := g.checkCurrent("relation", id); err != nil {
+		return err
+	}
+
+	relations, err := g.db.ExtractRelations([]int64{id})
+	if err != nil {
+		return err
+	}
+	if len(relations) == 0 {
+		return ErrElementNotFound
+	}
+	wayIDs, err := g.db.SelectWaysFromRelations([]int64{id})
+	if err != nil {
+		return err
+	}
+	ways, err := g.db.ExtractWays(wayIDs)
+	if err != nil {
+		return err
+	}
+	nodeIDs, err := g.db.SelectNodesFromWays(wayIDs)
+	if err != nil {
+		return err
+	}
+	nodes, err := g.db.ExtractNodes(uniqueIDs(nodeIDs))
+	if err != nil {
+		return err
+	}
+
+	polygons := relations[0].Polygons(ways, nodes)
+	if len(polygons) == 0 {
+		return BadRequest("The relation %v has no closed outline", id)
+	}
+	return g.PolygonMapHandler(polygons, w)
+}
+
+// selectPolygonNodes selects ids of nodes inside the polygons. The bbox is split into
+// cells like a quadtree: cells outside the polygons are skipped, all nodes of cells inside
+// them are taken and cells crossed by the edges are split while they have more than chunk
+// nodes, so only nodes near the edges are clipped one by one.
+// Error is returned if there are more than limit nodes inside the polygons.
+func selectPolygonNodes(s polygonSelector, polygons []osm.Polygon, bbox BBox, limit, chunk int) ([]int64, error) {
+	c := &polygonCollector{s: s, idx: newPolygonIndex(polygons), limit: limit, chunk: chunk, seen: map[int64]bool{}}
+	if err := c.visit(bbox); err != nil {
+		return nil, err
+	}
+	return c.ids, nil
+}
+
+// polygonCollector collects nodes inside the polygons, nodes on borders of cells
+// are selected in both cells, so they are deduplicated
+type polygonCollector struct {
+	s            polygonSelector
+	idx          *polygonIndex
+	limit, chunk int
+	seen         map[int64]bool
+	ids          []int64
+}
+
+func (c *polygonCollector) visit(cell BBox) error {
+	tiles := osm.TileRanges(cell.Bounds())
+	switch c.idx.classify(float64(cell.MinLon)/scale, float64(cell.MinLat)/scale,
+		float64(cell.MaxLon)/scale, float64(cell.MaxLat)/scale) {
+	case rectOutside:
+		return nil
+	case rectInside:
+		// all nodes of the cell are inside, so more than limit nodes is an error anyway
+		nodes, err := c.s.SelectNodeCoordinatesFromBbox(tiles, cell.MinLon, cell.MinLat, cell.MaxLon, cell.MaxLat,
+			c.limit+1)
+		if err != nil {
+			return err
+		}
+		return c.add(nodes, false)
+	}
+
+	if cell.MaxLon-cell.MinLon <= minPolygonCell && cell.MaxLat-cell.MinLat <= minPolygonCell {
+		var after int64
+		for {
+			nodes, err := c.s.SelectNodeCoordinatesFromBboxAfter(tiles, cell.MinLon, cell.MinLat, cell.MaxLon, cell.MaxLat,
+				after, c.chunk)
+			if err != nil {
+				return err
+			}
+			if err := c.add(nodes, true); err != nil {
+				return err
+			}
+			if len(nodes) < c.chunk {
+				return nil
+			}
+			after = nodes[len(nodes)-1][0]
+		}
+	}
+
+	nodes, err := c.s.SelectNodeCoordinatesFromBbox(tiles, cell.MinLon, cell.MinLat, cell.MaxLon, cell.MaxLat, c.chunk+1)
+	if err != nil {
+		return err
+	}
+	if len(nodes) <= c.chunk {
+		return c.add(nodes, true)
+	}
+
+	midLon, midLat := cell.MinLon+(cell.MaxLon-cell.MinLon)/2, cell.MinLat+(cell.MaxLat-cell.MinLat)/2
+	for _, quarter := range []BBox{
+		{MinLon: cell.MinLon, MinLat: cell.MinLat, MaxLon: midLon, MaxLat: midLat},
+		{MinLon: midLon, MinLat: cell.MinLat, MaxLon: cell.MaxLon, MaxLat: midLat},
+		{MinLon: cell.MinLon, MinLat: midLat, MaxLon: midLon, MaxLat: cell.MaxLat},
+		{MinLon: midLon, MinLat: midLat, MaxLon: cell.MaxLon, MaxLat: cell.MaxLat},
+	} {
+		if err := c.visit(quarter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add adds the nodes, they are checked against the polygons if clip is set
+func (c *polygonCollector) add(nodes [][3]int64, clip bool) error {
+	for _, n := range nodes {
+		if c.seen[n[0]] || clip && !c.idx.contains(float64(n[2])/scale, float64(n[1])/scale) {
+			continue
+		}
+		c.seen[n[0]] = true
+		c.ids = append(c.ids, n[0])
+		if len(c.ids) > c.limit {
+			return errTooManyNodes(c.limit)
+		}
+	}
+	return nil
+}
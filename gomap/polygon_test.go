@@ -0,0 +1,124 @@
+package gomap
+
+import (
+	"testing"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+func TestParsePolygon(t *testing.T) {
+	// the example of the encoded polyline algorithm documentation
+	polygons, err := ParsePolygon("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	expected := osm.Ring{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}, {-120.2, 38.5}}
+	if len(polygons) != 1 || len(polygons[0]) != 1 || len(polygons[0][0]) != len(expected) {
+		t.Fatalf("incorrect polygons: %v", polygons)
+	}
+	for i, p := range polygons[0][0] {
+		if p != expected[i] {
+			t.Errorf("incorrect point %v, expected %v", p, expected[i])
+		}
+	}
+
+	polygons, err = ParsePolygon(`{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,1]]]]}}`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(polygons) != 1 || len(polygons[0][0]) != 5 {
+		t.Errorf("ring isn't closed: %v", polygons)
+	}
+
+	for _, raw := range []string{"", `{"type":"Point","coordinates":[0,0]}`, `{"type":"Polygon","coordinates":[[[0,0],[1,1]]]}`, "_p~iF",
+		`{"type":"Polygon","coordinates":[]}`, `{"type":"MultiPolygon","coordinates":[[]]}`} {
+		if _, err := ParsePolygon(raw); err == nil {
+			t.Errorf("invalid polygon %q is parsed", raw)
+		}
+	}
+}
+
+func TestPolygonIndex(t *testing.T) {
+	polygons := []osm.Polygon{{
+		{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+		{{1, 1}, {1, 3}, {3, 3}, {3, 1}, {1, 1}},
+	}}
+	idx := newPolygonIndex(polygons)
+
+	cases := []struct {
+		lon, lat float64
+		inside   bool
+	}{
+		{0.5, 0.5, true},
+		{3.5, 2, true},
+		{2, 2, false},
+		{5, 2, false},
+		{2, -1, false},
+	}
+	for _, tc := range cases {
+		if idx.contains(tc.lon, tc.lat) != tc.inside {
+			t.Errorf("incorrect containment of %v,%v, expected %v", tc.lon, tc.lat, tc.inside)
+		}
+	}
+
+	rects := []struct {
+		minLon, minLat, maxLon, maxLat float64
+		position                       int
+	}{
+		{0.2, 0.2, 0.8, 0.8, rectInside},
+		{1.2, 1.2, 2.8, 2.8, rectOutside},
+		{5, 5, 6, 6, rectOutside},
+		{0.5, 0.5, 1.5, 1.5, rectCrossed},
+		{-1, -1, 5, 5, rectCrossed},
+		{3.5, -1, 3.8, 5, rectCrossed},
+	}
+	for _, r := range rects {
+		if p := idx.classify(r.minLon, r.minLat, r.maxLon, r.maxLat); p != r.position {
+			t.Errorf("incorrect position %v of %v, expected %v", p, r, r.position)
+		}
+	}
+
+	bbox := polygonBBox(polygons)
+	if bbox != (BBox{MinLon: 0, MinLat: 0, MaxLon: 4 * scale, MaxLat: 4 * scale}) {
+		t.Errorf("incorrect bbox: %+v", bbox)
+	}
+}
+
+func TestSelectPolygonNodes(t *testing.T) {
+	// grid of nodes with 0.001 degree spacing, so the smallest cells have many nodes, node 0 is deleted
+	o := osm.New()
+	for i := 0; i < 10000; i++ {
+		lat, lon := float64(i/100)/1000, float64(i%100)/1000
+		o.Nodes = append(o.Nodes, &osm.Node{ID: int64(i), Lat: &lat, Lon: &lon, Visible: i != 0})
+	}
+	s := fixtureSelector{o}
+
+	// thin diagonal strip, its bbox contains all nodes
+	polygons := []osm.Polygon{{{{0, 0}, {0.02, 0}, {0.1, 0.08}, {0.1, 0.1}, {0.08, 0.1}, {0, 0.02}, {0, 0}}}}
+	idx := newPolygonIndex(polygons)
+	expected := map[int64]bool{}
+	for _, n := range o.Nodes {
+		if n.Visible && idx.contains(*n.Lon, *n.Lat) {
+			expected[n.ID] = true
+		}
+	}
+
+	for _, chunk := range []int{1, 7, 100, 100000} {
+		ids, err := selectPolygonNodes(s, polygons, polygonBBox(polygons), len(expected), chunk)
+		if err != nil {
+			t.Fatalf("select error with chunk %v: %v", chunk, err)
+		}
+		if len(ids) != len(expected) {
+			t.Errorf("incorrect number of nodes %v with chunk %v, expected %v", len(ids), chunk, len(expected))
+		}
+		for _, id := range ids {
+			if !expected[id] {
+				t.Errorf("node %v outside the polygon is selected with chunk %v", id, chunk)
+			}
+		}
+	}
+
+	if _, err := selectPolygonNodes(s, polygons, polygonBBox(polygons), len(expected)-1, 100); err == nil {
+		t.Error("nodes over the limit are selected")
+	}
+}
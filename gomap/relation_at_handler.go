@@ -0,0 +1,51 @@
+package gomap
+
+import (
+	"time"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// RelationAtHandler returns data for /api/0.6/relation/:id?at=... request
+func (g *Gomap) RelationAtHandler(id int64, date time.Time) (*osm.OSM, error) {
+	relation, err := relationAt(g.db, id, date)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Relations = osm.Relations{relation}
+	return resp, nil
+}
+
+// relationAt returns the version of the relation which was the latest at the date
+func relationAt(s versionSelector, id int64, date time.Time) (*osm.Relation, error) {
+	versions, err := s.SelectRelationVersionsAt([]int64{id}, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrElementNotFound
+	}
+
+	relations, err := s.ExtractHistoricalRelations(versions)
+	if err != nil {
+		return nil, err
+	}
+	if len(relations) == 0 {
+		return nil, ErrElementNotFound
+	}
+	if !relations[0].Visible {
+		return nil, ErrElementDeleted
+	}
+	return relations[0], nil
+}
+
+// RelationFullAtHandler is used to get data for /api/0.6/relation/:id/full?at=... request
+func (g *Gomap) RelationFullAtHandler(id int64, date time.Time, w osm.Writer) error {
+	relation, err := relationAt(g.db, id, date)
+	if err != nil {
+		return err
+	}
+	return g.relationFullAt(relation, date, w)
+}
@@ -5,62 +5,51 @@ import (
 )
 
 // RelationFullHandler is used to get data for /api/0.6/relation/.../full request
-func (g *Gomap) RelationFullHandler(id int64) (*osm.OSM, error) {
+func (g *Gomap) RelationFullHandler(id int64, w osm.Writer) error {
 	ids, err := g.db.SelectRelations(id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if len(ids) == 0 {
-		return nil, ErrElementNotFound
+		return ErrElementNotFound
 	}
 
 	for i := range ids {
 		isVisible, err := g.db.IsRelationVisible(ids[i])
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if !isVisible {
-			return nil, ErrElementDeleted
+			return ErrElementDeleted
 		}
 	}
 
 	nodesFromRelations, err := g.db.SelectNodesFromRelations(ids)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	waysFromRelations, err := g.db.SelectWaysFromRelations(ids)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	nodesFromWays, err := g.db.SelectNodesFromWays(waysFromRelations)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	relationsFromRelations, err := g.db.SelectRelationMembersFromRelations(ids)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	nodeIDs := append(nodesFromRelations, nodesFromWays...)
 	wayIDs := waysFromRelations
 	relationIDs := append(ids, relationsFromRelations...)
 
-	nodes, err := g.db.ExtractNodes(nodeIDs)
-	if err != nil {
-		return nil, err
+	if err := g.db.StreamNodes(nodeIDs, w.WriteNode); err != nil {
+		return err
 	}
-	ways, err := g.db.ExtractWays(wayIDs)
-	if err != nil {
-		return nil, err
+	if err := g.db.StreamWays(wayIDs, w.WriteWay); err != nil {
+		return err
 	}
-	relations, err := g.db.ExtractRelations(relationIDs)
-	if err != nil {
-		return nil, err
-	}
-
-	resp := osm.New()
-	resp.Nodes = nodes
-	resp.Ways = ways
-	resp.Relations = relations
-	return resp, nil
+	return g.db.StreamRelations(relationIDs, w.WriteRelation)
 }
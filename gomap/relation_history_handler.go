@@ -2,22 +2,25 @@ package gomap
 
 import "github.com/osmlab/gomap/osm"
 
-// RelationHistoryHandler is used to get data for /api/0.6/relation/.../history request
-func (g *Gomap) RelationHistoryHandler(id int64) (*osm.OSM, error) {
-	ids, err := g.db.SelectRelationsHistory(id)
+// RelationHistoryHandler is used to get data for /api/0.6/relation/.../history request.
+// Versions in the range are returned with the first version of the next page,
+// which is 0 if there are no more versions.
+func (g *Gomap) RelationHistoryHandler(id int64, r HistoryRange) (*osm.OSM, int64, error) {
+	ids, next, err := selectHistoryPage(id, r, g.db.SelectRelationHistoryRange, g.db.SelectRelationsHistory)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+
+	resp := osm.New()
 	if len(ids) == 0 {
-		return nil, ErrElementNotFound
+		return resp, 0, nil
 	}
 
 	relations, err := g.db.ExtractHistoricalRelations(ids)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	resp := osm.New()
 	resp.Relations = relations
-	return resp, nil
+	return stripDeleted(resp), next, nil
 }
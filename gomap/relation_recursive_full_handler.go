@@ -0,0 +1,108 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// maxRecursiveElements is the largest number of elements of recursive relation full request
+const maxRecursiveElements = 50000
+
+// relationTreeSelector selects ids of relation members, it's implemented by the database
+type relationTreeSelector interface {
+	SelectRelationMembersFromRelations(ids []int64) ([]int64, error)
+	SelectWaysFromRelations(ids []int64) ([]int64, error)
+	SelectNodesFromRelations(ids []int64) ([]int64, error)
+	SelectNodesFromWays(ids []int64) ([]int64, error)
+}
+
+// RecursiveRelationFullHandler is used to get data for /api/0.6/relation/.../full?recurse=all request.
+// Members of nested relations are resolved down to depth levels, all levels if depth is 0.
+// Depth 1 resolves members of the relation only like the plain full request.
+func (g *Gomap) RecursiveRelationFullHandler(id int64, depth int, w osm.Writer) error {
+	ids, err := g.db.SelectRelations(id)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrElementNotFound
+	}
+	isVisible, err := g.db.IsRelationVisible(id)
+	if err != nil {
+		return err
+	}
+	if !isVisible {
+		return ErrElementDeleted
+	}
+
+	nodeIDs, wayIDs, relationIDs, err := selectRelationTree(g.db, ids, depth, maxRecursiveElements)
+	if err != nil {
+		return err
+	}
+
+	if err := g.db.StreamNodes(nodeIDs, w.WriteNode); err != nil {
+		return err
+	}
+	if err := g.db.StreamWays(wayIDs, w.WriteWay); err != nil {
+		return err
+	}
+	return g.db.StreamRelations(relationIDs, w.WriteRelation)
+}
+
+// selectRelationTree selects ids of the relations with all nested relations,
+// their ways and nodes. Every relation is visited once, so membership cycles
+// end the recursion. Members of depth levels of relations are selected, the relations
+// of the next level are returned without their members. Error is returned if there are more than limit elements.
+func selectRelationTree(s relationTreeSelector, ids []int64, depth, limit int) (nodeIDs, wayIDs, relationIDs []int64, err error) {
+	errTooMany := BadRequest("The relation has too many elements (limit is %v)", limit)
+
+	relationIDs = uniqueIDs(ids)
+	expanded := relationIDs
+	frontier := relationIDs
+	seen := map[int64]bool{}
+	for _, id := range relationIDs {
+		seen[id] = true
+	}
+	for level := 1; ; level++ {
+		children, err := s.SelectRelationMembersFromRelations(frontier)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var fresh []int64
+		for _, id := range children {
+			if !seen[id] {
+				seen[id] = true
+				fresh = append(fresh, id)
+			}
+		}
+		if len(fresh) == 0 {
+			break
+		}
+		relationIDs = append(relationIDs, fresh...)
+		if len(relationIDs) > limit {
+			return nil, nil, nil, errTooMany
+		}
+		if depth != 0 && level >= depth {
+			break
+		}
+		expanded = append(expanded, fresh...)
+		frontier = fresh
+	}
+
+	wayIDs, err = s.SelectWaysFromRelations(expanded)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	wayIDs = uniqueIDs(wayIDs)
+	nodesFromRelations, err := s.SelectNodesFromRelations(expanded)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nodesFromWays, err := s.SelectNodesFromWays(wayIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nodeIDs = uniqueIDs(nodesFromRelations, nodesFromWays)
+
+	if len(nodeIDs)+len(wayIDs)+len(relationIDs) > limit {
+		return nil, nil, nil, errTooMany
+	}
+	return nodeIDs, wayIDs, relationIDs, nil
+}
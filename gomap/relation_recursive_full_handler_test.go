@@ -0,0 +1,71 @@
+package gomap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectRelationTree(t *testing.T) {
+	s := fixtureSelector{loadFixture(t, "testdata/relations.osm")}
+
+	cases := []struct {
+		name      string
+		depth     int
+		limit     int
+		nodes     []int64
+		ways      []int64
+		relations []int64
+		err       bool
+	}{
+		{
+			name:      "all levels",
+			limit:     maxRecursiveElements,
+			nodes:     []int64{3, 4, 1, 2},
+			ways:      []int64{10},
+			relations: []int64{1, 2, 3, 4},
+		},
+		{
+			name:      "depth of plain full",
+			depth:     1,
+			limit:     maxRecursiveElements,
+			nodes:     []int64{},
+			ways:      []int64{},
+			relations: []int64{1, 2},
+		},
+		{
+			name:      "depth",
+			depth:     2,
+			limit:     maxRecursiveElements,
+			nodes:     []int64{1, 2},
+			ways:      []int64{10},
+			relations: []int64{1, 2, 3},
+		},
+		{
+			name:      "depth of all levels",
+			depth:     3,
+			limit:     maxRecursiveElements,
+			nodes:     []int64{3, 1, 2},
+			ways:      []int64{10},
+			relations: []int64{1, 2, 3, 4},
+		},
+		{name: "limit", limit: 8, err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, ways, relations, err := selectRelationTree(s, []int64{1}, tc.depth, tc.limit)
+			if (err != nil) != tc.err {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if !reflect.DeepEqual(nodes, tc.nodes) {
+				t.Errorf("incorrect nodes: %v, expected %v", nodes, tc.nodes)
+			}
+			if !reflect.DeepEqual(ways, tc.ways) {
+				t.Errorf("incorrect ways: %v, expected %v", ways, tc.ways)
+			}
+			if !reflect.DeepEqual(relations, tc.relations) {
+				t.Errorf("incorrect relations: %v, expected %v", relations, tc.relations)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// RelationVersionFullHandler is used to get data for /api/0.6/relation/:id/:version/full request.
+// Members are resolved to the versions which were valid at the timestamp of the relation version.
+func (g *Gomap) RelationVersionFullHandler(id, version int64, w osm.Writer) error {
+	relation, err := relationVersion(g.db, id, version)
+	if err != nil {
+		return err
+	}
+	return g.relationFullAt(relation, versionDate(relation.Timestamp), w)
+}
+
+// relationVersion returns the visible version of the relation, redacted versions
+// aren't extracted by the database, so they are not found
+func relationVersion(s versionSelector, id, version int64) (*osm.Relation, error) {
+	relations, err := s.ExtractHistoricalRelations([][2]int64{{id, version}})
+	if err != nil {
+		return nil, err
+	}
+	if len(relations) == 0 {
+		return nil, ErrElementNotFound
+	}
+	if !relations[0].Visible {
+		return nil, ErrElementDeleted
+	}
+	return relations[0], nil
+}
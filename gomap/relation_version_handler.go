@@ -17,5 +17,5 @@ func (g *Gomap) RelationVersionHandler(id, version int64) (*osm.OSM, error) {
 
 	resp := osm.New()
 	resp.Relations = relations
-	return resp, nil
+	return stripDeleted(resp), nil
 }
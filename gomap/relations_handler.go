@@ -4,6 +4,7 @@ import "github.com/osmlab/gomap/osm"
 
 // RelationsHandler is used to get data for /api/0.6/relations?relations=... request
 func (g *Gomap) RelationsHandler(ids []int64, histIDs [][2]int64) (*osm.OSM, error) {
+	ids = uniqueIDs(ids)
 	current, err := g.db.ExtractRelations(ids)
 	if err != nil {
 		return nil, err
@@ -22,5 +23,5 @@ func (g *Gomap) RelationsHandler(ids []int64, histIDs [][2]int64) (*osm.OSM, err
 
 	resp := osm.New()
 	resp.Relations = relations
-	return resp, nil
+	return stripDeleted(resp), nil
 }
@@ -0,0 +1,24 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// RelationsHistoryHandler is used to get data for /api/0.6/relations/history?relations=... request
+func (g *Gomap) RelationsHistoryHandler(ids []int64) (*osm.OSM, error) {
+	ids = uniqueIDs(ids)
+	versions, err := g.db.SelectRelationsHistory(ids...)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHistoryVersions(ids, versions); err != nil {
+		return nil, err
+	}
+
+	relations, err := g.db.ExtractHistoricalRelations(versions)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Relations = relations
+	return stripDeleted(resp), nil
+}
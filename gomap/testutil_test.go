@@ -0,0 +1,530 @@
+package gomap
+
+import (
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// Fixture selectors implement the narrow selector interface of each handler
+// on top of osm fixtures, elements are selected like the database does.
+var (
+	_ mapSelector          = fixtureSelector{}
+	_ nodeWaysSelector     = fixtureSelector{}
+	_ graphSelector        = fixtureSelector{}
+	_ relationTreeSelector = fixtureSelector{}
+	_ memberSelector       = fixtureSelector{}
+	_ polygonSelector      = fixtureSelector{}
+
+	_ historicalMapSelector  = historyFixture{}
+	_ versionSelector        = historyFixture{}
+	_ reverseHistorySelector = historyFixture{}
+)
+
+// loadFixture reads elements of the osm xml fixture
+func loadFixture(t *testing.T, name string) *osm.OSM {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("fixture error: %v", err)
+	}
+	defer f.Close()
+
+	o := osm.New()
+	if err := osm.Copy(o, osm.NewXMLScanner(f)); err != nil {
+		t.Fatalf("fixture error: %v", err)
+	}
+	return o
+}
+
+func contains(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// fixtureSelector selects current elements of osm fixture like the database does
+type fixtureSelector struct {
+	*osm.OSM
+}
+
+func (s fixtureSelector) SelectNodesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64) ([]int64, error) {
+	var ids []int64
+	for _, n := range s.Nodes {
+		if !n.Visible {
+			continue
+		}
+		lat, lon := fixedPoint(*n.Lat), fixedPoint(*n.Lon)
+		if lat >= minLat && lat <= maxLat && lon >= minLon && lon <= maxLon {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (s fixtureSelector) SelectWaysFromNodes(ids ...int64) ([]int64, error) {
+	var result []int64
+	for _, w := range s.Ways {
+		for _, n := range w.Nodes {
+			if w.Visible && contains(ids, n.ID) {
+				result = append(result, w.ID)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s fixtureSelector) SelectNodesFromWays(ids []int64) ([]int64, error) {
+	var result []int64
+	for _, w := range s.Ways {
+		if !contains(ids, w.ID) {
+			continue
+		}
+		for _, n := range w.Nodes {
+			result = append(result, n.ID)
+		}
+	}
+	return result, nil
+}
+
+func (s fixtureSelector) selectRelations(typ string, ids []int64) []int64 {
+	var result []int64
+	for _, r := range s.Relations {
+		for _, m := range r.Members {
+			if r.Visible && m.Type == typ && contains(ids, m.Ref) {
+				result = append(result, r.ID)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (s fixtureSelector) SelectRelationsFromNodes(ids []int64) ([]int64, error) {
+	return s.selectRelations("node", ids), nil
+}
+
+func (s fixtureSelector) SelectRelationsFromWays(ids []int64) ([]int64, error) {
+	return s.selectRelations("way", ids), nil
+}
+
+func (s fixtureSelector) SelectRelationsFromRelations(ids []int64) ([]int64, error) {
+	return s.selectRelations("relation", ids), nil
+}
+
+func (s fixtureSelector) SelectNodes(ids ...int64) ([]int64, error) {
+	var result []int64
+	for _, n := range s.Nodes {
+		if contains(ids, n.ID) {
+			result = append(result, n.ID)
+		}
+	}
+	return result, nil
+}
+
+func (s fixtureSelector) ExtractWays(ids []int64) (osm.Ways, error) {
+	var result osm.Ways
+	for _, w := range s.Ways {
+		if contains(ids, w.ID) {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+
+func (s fixtureSelector) ExtractRelations(ids []int64) (osm.Relations, error) {
+	var result osm.Relations
+	for _, r := range s.Relations {
+		if contains(ids, r.ID) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (s fixtureSelector) selectMembers(typ string, ids []int64) []int64 {
+	var result []int64
+	for _, r := range s.Relations {
+		if !contains(ids, r.ID) {
+			continue
+		}
+		for _, m := range r.Members {
+			if m.Type == typ {
+				result = append(result, m.Ref)
+			}
+		}
+	}
+	return result
+}
+
+func (s fixtureSelector) SelectRelationMembersFromRelations(ids []int64) ([]int64, error) {
+	return s.selectMembers("relation", ids), nil
+}
+
+func (s fixtureSelector) SelectWaysFromRelations(ids []int64) ([]int64, error) {
+	return s.selectMembers("way", ids), nil
+}
+
+func (s fixtureSelector) SelectNodesFromRelations(ids []int64) ([]int64, error) {
+	return s.selectMembers("node", ids), nil
+}
+
+func (s fixtureSelector) nodeCoordinates(minLon, minLat, maxLon, maxLat, after int64) [][3]int64 {
+	var result [][3]int64
+	for _, n := range s.Nodes {
+		if !n.Visible || n.ID <= after {
+			continue
+		}
+		lat, lon := fixedPoint(*n.Lat), fixedPoint(*n.Lon)
+		if lat >= minLat && lat <= maxLat && lon >= minLon && lon <= maxLon {
+			result = append(result, [3]int64{n.ID, lat, lon})
+		}
+	}
+	return result
+}
+
+func (s fixtureSelector) SelectNodeCoordinatesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+	limit int) ([][3]int64, error) {
+	result := s.nodeCoordinates(minLon, minLat, maxLon, maxLat, 0)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s fixtureSelector) SelectNodeCoordinatesFromBboxAfter(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+	after int64, limit int) ([][3]int64, error) {
+	result := s.nodeCoordinates(minLon, minLat, maxLon, maxLat, after)
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// historyFixture selects elements of osm history fixture as they were
+// at a date like the database does
+type historyFixture struct {
+	*osm.OSM
+}
+
+// redactedVersions are versions of the history fixture which are redacted
+var redactedVersions = map[string][][2]int64{
+	"node": {{7, 2}},
+	"way":  {{13, 2}},
+}
+
+func isRedacted(typ string, id int64, version int) bool {
+	for _, v := range redactedVersions[typ] {
+		if v == [2]int64{id, int64(version)} {
+			return true
+		}
+	}
+	return false
+}
+
+// loadHistory reads the history fixture without redacted versions,
+// all statements of the database skip rows with redaction_id
+func loadHistory(t *testing.T) historyFixture {
+	o := loadFixture(t, "testdata/history.osm")
+	h := historyFixture{osm.New()}
+	for _, n := range o.Nodes {
+		if !isRedacted("node", n.ID, n.Version) {
+			h.Nodes = append(h.Nodes, n)
+		}
+	}
+	for _, w := range o.Ways {
+		if !isRedacted("way", w.ID, w.Version) {
+			h.Ways = append(h.Ways, w)
+		}
+	}
+	for _, r := range o.Relations {
+		if !isRedacted("relation", r.ID, r.Version) {
+			h.Relations = append(h.Relations, r)
+		}
+	}
+	return h
+}
+
+// nodesAt returns ids of nodes existing at the date and their latest versions
+func (h historyFixture) nodesAt(date time.Time) ([]int64, map[int64]*osm.Node) {
+	var ids []int64
+	latest := map[int64]*osm.Node{}
+	for _, n := range h.Nodes {
+		if time.Time(n.Timestamp).After(date) {
+			continue
+		}
+		if _, ok := latest[n.ID]; !ok {
+			ids = append(ids, n.ID)
+		}
+		if latest[n.ID] == nil || latest[n.ID].Version < n.Version {
+			latest[n.ID] = n
+		}
+	}
+	return ids, latest
+}
+
+// waysAt returns ids of ways existing at the date and their latest versions
+func (h historyFixture) waysAt(date time.Time) ([]int64, map[int64]*osm.Way) {
+	var ids []int64
+	latest := map[int64]*osm.Way{}
+	for _, w := range h.Ways {
+		if time.Time(w.Timestamp).After(date) {
+			continue
+		}
+		if _, ok := latest[w.ID]; !ok {
+			ids = append(ids, w.ID)
+		}
+		if latest[w.ID] == nil || latest[w.ID].Version < w.Version {
+			latest[w.ID] = w
+		}
+	}
+	return ids, latest
+}
+
+// relationsAt returns ids of relations existing at the date and their latest versions
+func (h historyFixture) relationsAt(date time.Time) ([]int64, map[int64]*osm.Relation) {
+	var ids []int64
+	latest := map[int64]*osm.Relation{}
+	for _, r := range h.Relations {
+		if time.Time(r.Timestamp).After(date) {
+			continue
+		}
+		if _, ok := latest[r.ID]; !ok {
+			ids = append(ids, r.ID)
+		}
+		if latest[r.ID] == nil || latest[r.ID].Version < r.Version {
+			latest[r.ID] = r
+		}
+	}
+	return ids, latest
+}
+
+func (h historyFixture) SelectHistoricalNodesFromBbox(tiles []osm.TileRange, minLon, minLat, maxLon, maxLat int64,
+	date time.Time, limit int) ([]int64, error) {
+	var result []int64
+	ids, latest := h.nodesAt(date)
+	for _, id := range ids {
+		n := latest[id]
+		if !n.Visible || len(result) == limit {
+			continue
+		}
+		lat, lon := fixedPoint(*n.Lat), fixedPoint(*n.Lon)
+		if lat >= minLat && lat <= maxLat && lon >= minLon && lon <= maxLon {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectHistoricalWaysFromNodes(ids []int64, date time.Time) ([]int64, error) {
+	var result []int64
+	wayIDs, latest := h.waysAt(date)
+	for _, id := range wayIDs {
+		for _, n := range latest[id].Nodes {
+			if latest[id].Visible && contains(ids, n.ID) {
+				result = append(result, id)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectHistoricalNodesFromWays(ids []int64, date time.Time) ([]int64, error) {
+	var result []int64
+	wayIDs, latest := h.waysAt(date)
+	for _, id := range wayIDs {
+		if !contains(ids, id) {
+			continue
+		}
+		for _, n := range latest[id].Nodes {
+			result = append(result, n.ID)
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) selectRelations(typ string, ids []int64, date time.Time) []int64 {
+	var result []int64
+	relationIDs, latest := h.relationsAt(date)
+	for _, id := range relationIDs {
+		for _, m := range latest[id].Members {
+			if latest[id].Visible && m.Type == typ && contains(ids, m.Ref) {
+				result = append(result, id)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (h historyFixture) SelectHistoricalRelationsFromNodes(ids []int64, date time.Time) ([]int64, error) {
+	return h.selectRelations("node", ids, date), nil
+}
+
+func (h historyFixture) SelectHistoricalRelationsFromWays(ids []int64, date time.Time) ([]int64, error) {
+	return h.selectRelations("way", ids, date), nil
+}
+
+func (h historyFixture) SelectHistoricalRelationsFromRelations(ids []int64, date time.Time) ([]int64, error) {
+	return h.selectRelations("relation", ids, date), nil
+}
+
+func (h historyFixture) SelectNodeVersionsAt(ids []int64, date time.Time) ([][2]int64, error) {
+	var result [][2]int64
+	_, latest := h.nodesAt(date)
+	for _, id := range ids {
+		if n, ok := latest[id]; ok {
+			result = append(result, [2]int64{id, int64(n.Version)})
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectWayVersionsAt(ids []int64, date time.Time) ([][2]int64, error) {
+	var result [][2]int64
+	_, latest := h.waysAt(date)
+	for _, id := range ids {
+		if w, ok := latest[id]; ok {
+			result = append(result, [2]int64{id, int64(w.Version)})
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectRelationVersionsAt(ids []int64, date time.Time) ([][2]int64, error) {
+	var result [][2]int64
+	_, latest := h.relationsAt(date)
+	for _, id := range ids {
+		if r, ok := latest[id]; ok {
+			result = append(result, [2]int64{id, int64(r.Version)})
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) ExtractHistoricalNodes(ids [][2]int64) (osm.Nodes, error) {
+	var result osm.Nodes
+	for _, v := range ids {
+		for _, n := range h.Nodes {
+			if n.ID == v[0] && int64(n.Version) == v[1] {
+				result = append(result, n)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) ExtractHistoricalWays(ids [][2]int64) (osm.Ways, error) {
+	var result osm.Ways
+	for _, v := range ids {
+		for _, w := range h.Ways {
+			if w.ID == v[0] && int64(w.Version) == v[1] {
+				result = append(result, w)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) ExtractHistoricalRelations(ids [][2]int64) (osm.Relations, error) {
+	var result osm.Relations
+	for _, v := range ids {
+		for _, r := range h.Relations {
+			if r.ID == v[0] && int64(r.Version) == v[1] {
+				result = append(result, r)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectNodesHistory(ids ...int64) ([][2]int64, error) {
+	var result [][2]int64
+	for _, n := range h.Nodes {
+		if contains(ids, n.ID) {
+			result = append(result, [2]int64{n.ID, int64(n.Version)})
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectWaysHistory(ids ...int64) ([][2]int64, error) {
+	var result [][2]int64
+	for _, w := range h.Ways {
+		if contains(ids, w.ID) {
+			result = append(result, [2]int64{w.ID, int64(w.Version)})
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectRelationsHistory(ids ...int64) ([][2]int64, error) {
+	var result [][2]int64
+	for _, r := range h.Relations {
+		if contains(ids, r.ID) {
+			result = append(result, [2]int64{r.ID, int64(r.Version)})
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) SelectWayVersionsFromNode(id int64) ([][2]int64, error) {
+	var result [][2]int64
+	for _, w := range h.Ways {
+		for _, n := range w.Nodes {
+			if n.ID == id {
+				result = append(result, [2]int64{w.ID, int64(w.Version)})
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (h historyFixture) relationVersionsFromMember(typ string, id int64) [][2]int64 {
+	var result [][2]int64
+	for _, r := range h.Relations {
+		for _, m := range r.Members {
+			if m.Type == typ && m.Ref == id {
+				result = append(result, [2]int64{r.ID, int64(r.Version)})
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (h historyFixture) SelectRelationVersionsFromNode(id int64) ([][2]int64, error) {
+	return h.relationVersionsFromMember("node", id), nil
+}
+
+func (h historyFixture) SelectRelationVersionsFromWay(id int64) ([][2]int64, error) {
+	return h.relationVersionsFromMember("way", id), nil
+}
+
+func (h historyFixture) SelectRelationVersionsFromRelation(id int64) ([][2]int64, error) {
+	return h.relationVersionsFromMember("relation", id), nil
+}
+
+// versionsOf returns id and version pairs of the elements
+func versionsOf(o *osm.OSM) map[string][][2]int64 {
+	result := map[string][][2]int64{}
+	for _, n := range o.Nodes {
+		result["node"] = append(result["node"], [2]int64{n.ID, int64(n.Version)})
+	}
+	for _, w := range o.Ways {
+		result["way"] = append(result["way"], [2]int64{w.ID, int64(w.Version)})
+	}
+	for _, r := range o.Relations {
+		result["relation"] = append(result["relation"], [2]int64{r.ID, int64(r.Version)})
+	}
+	return result
+}
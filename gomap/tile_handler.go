@@ -0,0 +1,33 @@
+package gomap
+
+import (
+	"math"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// TileHandler is used to get data for /tiles/:z/:x/:y.mvt request
+func (g *Gomap) TileHandler(z, x, y uint32) ([]byte, error) {
+	if z < g.config.MinTileZoom {
+		return nil, ErrZoomTooLow
+	}
+	if z > 30 || x >= 1<<z || y >= 1<<z {
+		return nil, ErrElementNotFound
+	}
+
+	minLon, minLat, maxLon, maxLat := osm.TileBounds(z, x, y)
+	bbox := BBox{MinLon: fixedPoint(minLon), MinLat: fixedPoint(minLat), MaxLon: fixedPoint(maxLon), MaxLat: fixedPoint(maxLat)}
+
+	resp := osm.New()
+	err := g.MapHandler(bbox, resp)
+	if err != nil && err != ErrElementNotFound {
+		return nil, err
+	}
+
+	return resp.MarshalMVT(z, x, y), nil
+}
+
+// fixedPoint converts degrees to the database coordinates
+func fixedPoint(v float64) int64 {
+	return int64(math.Round(v * scale))
+}
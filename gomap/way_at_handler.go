@@ -0,0 +1,51 @@
+package gomap
+
+import (
+	"time"
+
+	"github.com/osmlab/gomap/osm"
+)
+
+// WayAtHandler returns data for /api/0.6/way/:id?at=... request
+func (g *Gomap) WayAtHandler(id int64, date time.Time) (*osm.OSM, error) {
+	way, err := wayAt(g.db, id, date)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Ways = osm.Ways{way}
+	return resp, nil
+}
+
+// wayAt returns the version of the way which was the latest at the date
+func wayAt(s versionSelector, id int64, date time.Time) (*osm.Way, error) {
+	versions, err := s.SelectWayVersionsAt([]int64{id}, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrElementNotFound
+	}
+
+	ways, err := s.ExtractHistoricalWays(versions)
+	if err != nil {
+		return nil, err
+	}
+	if len(ways) == 0 {
+		return nil, ErrElementNotFound
+	}
+	if !ways[0].Visible {
+		return nil, ErrElementDeleted
+	}
+	return ways[0], nil
+}
+
+// WayFullAtHandler is used to get data for /api/0.6/way/:id/full?at=... request
+func (g *Gomap) WayFullAtHandler(id int64, date time.Time, w osm.Writer) error {
+	way, err := wayAt(g.db, id, date)
+	if err != nil {
+		return err
+	}
+	return g.wayFullAt(way, date, w)
+}
@@ -5,41 +5,32 @@ import (
 )
 
 // WayFullHandler is used to get data for /api/0.6/way/.../full request
-func (g *Gomap) WayFullHandler(id int64) (*osm.OSM, error) {
+func (g *Gomap) WayFullHandler(id int64, w osm.Writer) error {
 	ids, err := g.db.SelectWays(id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if len(ids) == 0 {
-		return nil, ErrElementNotFound
+		return ErrElementNotFound
 	}
 
 	for i := range ids {
 		isVisible, err := g.db.IsWayVisible(ids[i])
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if !isVisible {
-			return nil, ErrElementDeleted
+			return ErrElementDeleted
 		}
 	}
 
 	nodeIDs, err := g.db.SelectNodesFromWays(ids)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	nodes, err := g.db.ExtractNodes(nodeIDs)
-	if err != nil {
-		return nil, err
-	}
-	ways, err := g.db.ExtractWays(ids)
-	if err != nil {
-		return nil, err
+	if err := g.db.StreamNodes(nodeIDs, w.WriteNode); err != nil {
+		return err
 	}
-
-	resp := osm.New()
-	resp.Nodes = nodes
-	resp.Ways = ways
-	return resp, nil
+	return g.db.StreamWays(ids, w.WriteWay)
 }
@@ -2,22 +2,25 @@ package gomap
 
 import "github.com/osmlab/gomap/osm"
 
-// WayHistoryHandler is used to get data for /api/0.6/way/.../history request
-func (g *Gomap) WayHistoryHandler(id int64) (*osm.OSM, error) {
-	ids, err := g.db.SelectWaysHistory(id)
+// WayHistoryHandler is used to get data for /api/0.6/way/.../history request.
+// Versions in the range are returned with the first version of the next page,
+// which is 0 if there are no more versions.
+func (g *Gomap) WayHistoryHandler(id int64, r HistoryRange) (*osm.OSM, int64, error) {
+	ids, next, err := selectHistoryPage(id, r, g.db.SelectWayHistoryRange, g.db.SelectWaysHistory)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+
+	resp := osm.New()
 	if len(ids) == 0 {
-		return nil, ErrElementNotFound
+		return resp, 0, nil
 	}
 
 	ways, err := g.db.ExtractHistoricalWays(ids)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	resp := osm.New()
 	resp.Ways = ways
-	return resp, nil
+	return stripDeleted(resp), next, nil
 }
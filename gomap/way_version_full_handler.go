@@ -0,0 +1,29 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// WayVersionFullHandler is used to get data for /api/0.6/way/:id/:version/full request.
+// Members are resolved to the versions which were valid at the timestamp of the way version.
+func (g *Gomap) WayVersionFullHandler(id, version int64, w osm.Writer) error {
+	way, err := wayVersion(g.db, id, version)
+	if err != nil {
+		return err
+	}
+	return g.wayFullAt(way, versionDate(way.Timestamp), w)
+}
+
+// wayVersion returns the visible version of the way, redacted versions
+// aren't extracted by the database, so they are not found
+func wayVersion(s versionSelector, id, version int64) (*osm.Way, error) {
+	ways, err := s.ExtractHistoricalWays([][2]int64{{id, version}})
+	if err != nil {
+		return nil, err
+	}
+	if len(ways) == 0 {
+		return nil, ErrElementNotFound
+	}
+	if !ways[0].Visible {
+		return nil, ErrElementDeleted
+	}
+	return ways[0], nil
+}
@@ -0,0 +1,31 @@
+package gomap
+
+import "testing"
+
+func TestWayVersion(t *testing.T) {
+	h := loadHistory(t)
+
+	cases := []struct {
+		name    string
+		id      int64
+		version int64
+		err     error
+	}{
+		{name: "visible", id: 13, version: 1},
+		{name: "redacted", id: 13, version: 2, err: ErrElementNotFound},
+		{name: "deleted", id: 10, version: 3, err: ErrElementDeleted},
+		{name: "missing version", id: 10, version: 4, err: ErrElementNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			way, err := wayVersion(h, tc.id, tc.version)
+			if err != tc.err {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if err == nil && (way.ID != tc.id || int64(way.Version) != tc.version) {
+				t.Errorf("incorrect way %v version %v", way.ID, way.Version)
+			}
+		})
+	}
+}
@@ -17,5 +17,5 @@ func (g *Gomap) WayVersionHandler(id, version int64) (*osm.OSM, error) {
 
 	resp := osm.New()
 	resp.Ways = ways
-	return resp, nil
+	return stripDeleted(resp), nil
 }
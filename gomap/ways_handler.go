@@ -4,6 +4,7 @@ import "github.com/osmlab/gomap/osm"
 
 // WaysHandler is used to get data for /api/0.6/ways?ways=... request
 func (g *Gomap) WaysHandler(ids []int64, histIDs [][2]int64) (*osm.OSM, error) {
+	ids = uniqueIDs(ids)
 	current, err := g.db.ExtractWays(ids)
 	if err != nil {
 		return nil, err
@@ -22,5 +23,5 @@ func (g *Gomap) WaysHandler(ids []int64, histIDs [][2]int64) (*osm.OSM, error) {
 
 	resp := osm.New()
 	resp.Ways = ways
-	return resp, nil
+	return stripDeleted(resp), nil
 }
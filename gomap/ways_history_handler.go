@@ -0,0 +1,24 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// WaysHistoryHandler is used to get data for /api/0.6/ways/history?ways=... request
+func (g *Gomap) WaysHistoryHandler(ids []int64) (*osm.OSM, error) {
+	ids = uniqueIDs(ids)
+	versions, err := g.db.SelectWaysHistory(ids...)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHistoryVersions(ids, versions); err != nil {
+		return nil, err
+	}
+
+	ways, err := g.db.ExtractHistoricalWays(versions)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := osm.New()
+	resp.Ways = ways
+	return stripDeleted(resp), nil
+}
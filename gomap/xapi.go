@@ -0,0 +1,139 @@
+package gomap
+
+import (
+	"sort"
+	"strings"
+)
+
+// XAPIQuery is a tag search like /api/0.6/way[highway=motorway|trunk][bbox=...]
+type XAPIQuery struct {
+	// Type is node, way, relation or * for all of them
+	Type string
+	// Tags are predicates which all have to match
+	Tags []TagPredicate
+	// BBox limits elements to the area if it's set
+	BBox *BBox
+}
+
+// TagPredicate matches elements having a tag with one of the keys and one of the values,
+// empty values match any value
+type TagPredicate struct {
+	Keys, Values []string
+}
+
+var errXAPIPredicates = BadRequest(
+	"The predicates must be of the form [key=value], where keys and values are separated by |, * is any value.")
+
+// ParseXAPI parses the predicates of XAPI request like [amenity=hospital][bbox=...],
+// special characters are escaped by backslash
+func ParseXAPI(elementType, predicates string) (*XAPIQuery, error) {
+	switch elementType {
+	case "node", "way", "relation", "*":
+	default:
+		return nil, BadRequest("Unknown element type %q", elementType)
+	}
+
+	q := &XAPIQuery{Type: elementType}
+	for len(predicates) != 0 {
+		if predicates[0] != '[' {
+			return nil, errXAPIPredicates
+		}
+		end := indexUnescaped(predicates, ']')
+		if end == -1 {
+			return nil, errXAPIPredicates
+		}
+		predicate := predicates[1:end]
+		predicates = predicates[end+1:]
+
+		eq := indexUnescaped(predicate, '=')
+		if eq == -1 {
+			return nil, errXAPIPredicates
+		}
+		key, value := predicate[:eq], predicate[eq+1:]
+
+		if key == "bbox" {
+			if q.BBox != nil {
+				return nil, BadRequest("Only one bbox predicate can be set")
+			}
+			bbox, err := ParseBBox(value)
+			if err != nil {
+				return nil, err
+			}
+			q.BBox = &bbox
+			continue
+		}
+		if strings.HasPrefix(key, "@") {
+			return nil, BadRequest("The predicate %q is not supported", key)
+		}
+
+		var p TagPredicate
+		if p.Keys = splitUnescaped(key); p.Keys == nil {
+			return nil, errXAPIPredicates
+		}
+		if value != "*" {
+			if p.Values = splitUnescaped(value); p.Values == nil {
+				return nil, errXAPIPredicates
+			}
+		}
+		q.Tags = append(q.Tags, p)
+	}
+
+	if len(q.Tags) == 0 && q.BBox == nil {
+		return nil, BadRequest("At least one predicate is required")
+	}
+
+	// predicates with values are usually more selective, so they are looked up first
+	sort.SliceStable(q.Tags, func(i, j int) bool {
+		return len(q.Tags[i].Values) != 0 && len(q.Tags[j].Values) == 0
+	})
+	return q, nil
+}
+
+// indexUnescaped returns the index of the first c which isn't escaped by backslash
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitUnescaped splits s by unescaped | and unescapes the parts,
+// nil is returned if any part is empty
+func splitUnescaped(s string) []string {
+	var parts []string
+	for {
+		i := indexUnescaped(s, '|')
+		part := s
+		if i != -1 {
+			part = s[:i]
+		}
+		if len(part) == 0 {
+			return nil
+		}
+		parts = append(parts, unescape(part))
+		if i == -1 {
+			return parts
+		}
+		s = s[i+1:]
+	}
+}
+
+// unescape removes backslashes escaping the following characters
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
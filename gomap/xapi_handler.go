@@ -0,0 +1,174 @@
+package gomap
+
+import "github.com/osmlab/gomap/osm"
+
+// maxXAPIElements is the largest number of elements of each type matched by XAPI request
+const maxXAPIElements = 10000
+
+// errTooManyXAPIElements is returned when the predicates match too many elements
+var errTooManyXAPIElements = BadRequest("You requested too many elements (limit is %v). "+
+	"Either add more predicates, or request a smaller bbox", maxXAPIElements)
+
+// tagSelector selects elements of one type by tags
+type tagSelector struct {
+	withTag   func(keys, values []string, limit int) ([]int64, error)
+	filterTag func(ids []int64, keys, values []string) ([]int64, error)
+}
+
+// memberSelector selects members of ways and relations, it's implemented by the database
+type memberSelector interface {
+	SelectNodesFromWays(ids []int64) ([]int64, error)
+	SelectNodesFromRelations(ids []int64) ([]int64, error)
+	SelectWaysFromRelations(ids []int64) ([]int64, error)
+}
+
+// XAPIHandler is used to get data for XAPI requests like /api/0.6/node[amenity=hospital].
+// Matched ways are returned with all their nodes, matched relations with their node
+// and way members and nodes of these ways.
+func (g *Gomap) XAPIHandler(q *XAPIQuery, w osm.Writer) error {
+	if q.BBox != nil {
+		if err := g.checkMapArea(*q.BBox); err != nil {
+			return err
+		}
+	}
+
+	candidates := map[string][]int64{}
+	if q.BBox != nil {
+		var err error
+		if candidates, err = g.bboxCandidates(q); err != nil {
+			return err
+		}
+	}
+
+	selectors := map[string]tagSelector{
+		"node":     {g.db.SelectNodesWithTag, g.db.FilterNodesWithTag},
+		"way":      {g.db.SelectWaysWithTag, g.db.FilterWaysWithTag},
+		"relation": {g.db.SelectRelationsWithTag, g.db.FilterRelationsWithTag},
+	}
+	matched := map[string][]int64{}
+	for _, elementType := range []string{"node", "way", "relation"} {
+		if q.Type != "*" && q.Type != elementType {
+			continue
+		}
+		ids, err := selectTags(selectors[elementType], q.Tags, candidates[elementType], q.BBox != nil)
+		if err != nil {
+			return err
+		}
+		matched[elementType] = ids
+	}
+
+	nodeIDs, wayIDs, relationIDs, err := completeXAPI(g.db, matched)
+	if err != nil {
+		return err
+	}
+
+	if bw, ok := w.(osm.BoundsWriter); ok && q.BBox != nil {
+		if err := bw.WriteBounds(q.BBox.Bounds()); err != nil {
+			return err
+		}
+	}
+	if err := g.db.StreamNodes(nodeIDs, w.WriteNode); err != nil {
+		return err
+	}
+	if err := g.db.StreamWays(wayIDs, w.WriteWay); err != nil {
+		return err
+	}
+	return g.db.StreamRelations(relationIDs, w.WriteRelation)
+}
+
+// bboxCandidates selects elements of the requested types in the bbox:
+// nodes in it, ways using these nodes and relations with any of these nodes or ways as members
+func (g *Gomap) bboxCandidates(q *XAPIQuery) (map[string][]int64, error) {
+	bbox := q.BBox
+	nodeIDs, err := g.db.SelectNodesFromBbox(osm.TileRanges(bbox.Bounds()), bbox.MinLon, bbox.MinLat, bbox.MaxLon, bbox.MaxLat)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodeIDs) > maxNodes {
+		return nil, errTooManyNodes(maxNodes)
+	}
+
+	candidates := map[string][]int64{"node": nodeIDs}
+	if q.Type == "node" || len(nodeIDs) == 0 {
+		return candidates, nil
+	}
+	wayIDs, err := g.db.SelectWaysFromNodes(nodeIDs...)
+	if err != nil {
+		return nil, err
+	}
+	candidates["way"] = uniqueIDs(wayIDs)
+	if q.Type == "way" {
+		return candidates, nil
+	}
+
+	relationsFromNodes, err := g.db.SelectRelationsFromNodes(nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	relationsFromWays, err := g.db.SelectRelationsFromWays(candidates["way"])
+	if err != nil {
+		return nil, err
+	}
+	candidates["relation"] = uniqueIDs(relationsFromNodes, relationsFromWays)
+	return candidates, nil
+}
+
+// selectTags selects elements matching all predicates. The candidates are filtered
+// if they are limited by bbox, otherwise the first predicate is looked up in all elements.
+func selectTags(s tagSelector, predicates []TagPredicate, candidates []int64, limited bool) ([]int64, error) {
+	ids := candidates
+	if !limited {
+		var err error
+		ids, err = s.withTag(predicates[0].Keys, predicates[0].Values, maxXAPIElements+1)
+		if err != nil {
+			return nil, err
+		}
+		// rows are counted before deduplication, since the lookup stops at the limit
+		if len(ids) > maxXAPIElements {
+			return nil, errTooManyXAPIElements
+		}
+		ids = uniqueIDs(ids)
+		predicates = predicates[1:]
+	}
+
+	for _, p := range predicates {
+		if len(ids) == 0 {
+			return ids, nil
+		}
+		filtered, err := s.filterTag(ids, p.Keys, p.Values)
+		if err != nil {
+			return nil, err
+		}
+		ids = uniqueIDs(filtered)
+	}
+	if len(ids) > maxXAPIElements {
+		return nil, errTooManyXAPIElements
+	}
+	return ids, nil
+}
+
+// completeXAPI adds nodes of the matched ways, and node and way members of the matched
+// relations with nodes of these ways
+func completeXAPI(s memberSelector, matched map[string][]int64) (nodeIDs, wayIDs, relationIDs []int64, err error) {
+	relationIDs = uniqueIDs(matched["relation"])
+	relationNodes, err := s.SelectNodesFromRelations(relationIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	relationWays, err := s.SelectWaysFromRelations(relationIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	wayIDs = uniqueIDs(matched["way"], relationWays)
+	wayNodes, err := s.SelectNodesFromWays(wayIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nodeIDs = uniqueIDs(matched["node"], relationNodes, wayNodes)
+	if len(nodeIDs) > maxNodes {
+		return nil, nil, nil, errTooManyNodes(maxNodes)
+	}
+	return nodeIDs, wayIDs, relationIDs, nil
+}
@@ -0,0 +1,153 @@
+package gomap
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// fakeTags selects elements by tags like the database does, an id is
+// returned for every matching tag
+type fakeTags map[int64]map[string]string
+
+func (f fakeTags) match(id int64, keys, values []string) []int64 {
+	var result []int64
+	for _, k := range keys {
+		v, ok := f[id][k]
+		if ok && (len(values) == 0 || containsString(values, v)) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func (f fakeTags) selector() tagSelector {
+	var ids []int64
+	for id := range f {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return tagSelector{
+		withTag: func(keys, values []string, limit int) ([]int64, error) {
+			var result []int64
+			for _, id := range ids {
+				result = append(result, f.match(id, keys, values)...)
+			}
+			if len(result) > limit {
+				result = result[:limit]
+			}
+			return result, nil
+		},
+		filterTag: func(candidates []int64, keys, values []string) ([]int64, error) {
+			var result []int64
+			for _, id := range candidates {
+				result = append(result, f.match(id, keys, values)...)
+			}
+			return result, nil
+		},
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSelectTags(t *testing.T) {
+	s := fakeTags{
+		1: {"amenity": "cafe", "name": "A"},
+		2: {"amenity": "hospital"},
+		3: {"amenity": "cafe", "shop": "bakery"},
+	}.selector()
+
+	cases := []struct {
+		name       string
+		predicates string
+		candidates []int64
+		ids        []int64
+	}{
+		{name: "value", predicates: "[amenity=cafe]", ids: []int64{1, 3}},
+		{name: "all predicates", predicates: "[amenity=cafe][shop=*]", ids: []int64{3}},
+		{name: "alternatives", predicates: "[amenity|shop=cafe|bakery]", ids: []int64{1, 3}},
+		{name: "no match", predicates: "[amenity=cafe][name=B]", ids: []int64{}},
+		{name: "candidates", predicates: "[amenity=*][bbox=0,0,1,1]", candidates: []int64{2, 3}, ids: []int64{2, 3}},
+		{name: "no candidates", predicates: "[amenity=*][bbox=0,0,1,1]", candidates: []int64{}, ids: []int64{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := ParseXAPI("node", tc.predicates)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+			ids, err := selectTags(s, q.Tags, tc.candidates, q.BBox != nil)
+			if err != nil {
+				t.Fatalf("select error: %v", err)
+			}
+			if !reflect.DeepEqual(ids, tc.ids) {
+				t.Errorf("incorrect ids: %v, expected %v", ids, tc.ids)
+			}
+		})
+	}
+}
+
+func TestSelectTagsLimit(t *testing.T) {
+	many := fakeTags{}
+	for id := int64(1); id <= maxXAPIElements; id++ {
+		many[id] = map[string]string{"amenity": "cafe"}
+	}
+	// one element has both keys, so the rows exceed the limit before deduplication
+	many[1]["shop"] = "cafe"
+	s := many.selector()
+
+	if _, err := selectTags(s, []TagPredicate{{Keys: []string{"amenity"}}}, nil, false); err != nil {
+		t.Errorf("elements at the limit are rejected: %v", err)
+	}
+	if _, err := selectTags(s, []TagPredicate{{Keys: []string{"amenity", "shop"}}}, nil, false); err != errTooManyXAPIElements {
+		t.Errorf("incorrect error: %v", err)
+	}
+
+	candidates := make([]int64, 0, maxXAPIElements+1)
+	for id := int64(1); id <= maxXAPIElements+1; id++ {
+		candidates = append(candidates, id)
+	}
+	many[maxXAPIElements+1] = map[string]string{"amenity": "cafe"}
+	if _, err := selectTags(many.selector(), nil, candidates, true); err != errTooManyXAPIElements {
+		t.Errorf("incorrect error of too many candidates: %v", err)
+	}
+}
+
+func TestCompleteXAPI(t *testing.T) {
+	s := fixtureSelector{loadFixture(t, "testdata/map.osm")}
+
+	matched := map[string][]int64{
+		"node":     {5},
+		"way":      {12},
+		"relation": {25, 21},
+	}
+	nodes, ways, relations, err := completeXAPI(s, matched)
+	if err != nil {
+		t.Fatalf("complete error: %v", err)
+	}
+	// nodes of the way 12 and of the way 10 which is a member of the relation 21,
+	// node members of the relation 25
+	if expected := []int64{5, 3, 2, 1}; !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("incorrect nodes: %v, expected %v", nodes, expected)
+	}
+	if expected := []int64{12, 10}; !reflect.DeepEqual(ways, expected) {
+		t.Errorf("incorrect ways: %v, expected %v", ways, expected)
+	}
+	if expected := []int64{25, 21}; !reflect.DeepEqual(relations, expected) {
+		t.Errorf("incorrect relations: %v, expected %v", relations, expected)
+	}
+
+	nodes, ways, relations, err = completeXAPI(s, map[string][]int64{})
+	if err != nil || len(nodes) != 0 || len(ways) != 0 || len(relations) != 0 {
+		t.Errorf("empty match is completed with elements: %v %v %v %v", nodes, ways, relations, err)
+	}
+}
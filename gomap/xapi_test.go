@@ -0,0 +1,30 @@
+package gomap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseXAPI(t *testing.T) {
+	q, err := ParseXAPI("*", `[shop=*][highway=motorway|trunk][bbox=-0.5,51.25,0.25,51.75][name=a\]b\|c\=d]`)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if q.Type != "*" || q.BBox == nil || *q.BBox != (BBox{MinLon: -5000000, MinLat: 512500000, MaxLon: 2500000, MaxLat: 517500000}) {
+		t.Errorf("incorrect query: %+v", q)
+	}
+	expected := "[{[highway] [motorway trunk]} {[name] [a]b|c=d]} {[shop] []}]"
+	if got := fmt.Sprint(q.Tags); got != expected {
+		t.Errorf("incorrect tags %v, expected %v", got, expected)
+	}
+
+	for _, raw := range []string{"", "[amenity]", "[amenity=hospital", "amenity=hospital", "[=hospital]",
+		"[highway=motorway|]", "[@user=alice]", "[bbox=0,0,1,1][bbox=0,0,1,1]", "[bbox=1,0,0,1]"} {
+		if _, err := ParseXAPI("node", raw); err == nil {
+			t.Errorf("invalid predicates %q are parsed", raw)
+		}
+	}
+	if _, err := ParseXAPI("changeset", "[amenity=hospital]"); err == nil {
+		t.Error("unknown element type is parsed")
+	}
+}
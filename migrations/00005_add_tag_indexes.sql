@@ -0,0 +1,9 @@
+-- +goose Up
+create index if not exists current_node_tags_k_v_idx on current_node_tags (k, v);
+create index if not exists current_way_tags_k_v_idx on current_way_tags (k, v);
+create index if not exists current_relation_tags_k_v_idx on current_relation_tags (k, v);
+
+-- +goose Down
+drop index if exists current_node_tags_k_v_idx;
+drop index if exists current_way_tags_k_v_idx;
+drop index if exists current_relation_tags_k_v_idx;
@@ -0,0 +1,181 @@
+package osm
+
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+)
+
+// Blame tells which versions set the tags, way nodes and relation members
+// of the latest version of an element
+type Blame struct {
+	XMLName xml.Name        `xml:"blame" json:"-"`
+	Type    string          `xml:"type,attr" json:"type"`
+	ID      int64           `xml:"id,attr" json:"id"`
+	Version int             `xml:"version,attr" json:"version"`
+	Tags    []*TagBlame     `xml:"tag" json:"tags"`
+	Nodes   []*WayNodeBlame `xml:"nd" json:"nodes,omitempty"`
+	Members []*MemberBlame  `xml:"member" json:"members,omitempty"`
+}
+
+// BlameVersion is the version which set a value
+type BlameVersion struct {
+	Version     int    `xml:"version,attr" json:"version"`
+	ChangesetID int64  `xml:"changeset,attr" json:"changeset"`
+	Timestamp   Time   `xml:"timestamp,attr" json:"timestamp"`
+	User        string `xml:"user,attr,omitempty" json:"user,omitempty"`
+	UserID      int64  `xml:"uid,attr,omitempty" json:"uid,omitempty"`
+}
+
+// TagBlame is a tag with the version which set its value
+type TagBlame struct {
+	K string `xml:"k,attr" json:"k"`
+	V string `xml:"v,attr" json:"v"`
+	BlameVersion
+}
+
+// WayNodeBlame is a way node with the version which inserted it
+type WayNodeBlame struct {
+	Ref int64 `xml:"ref,attr" json:"ref"`
+	BlameVersion
+}
+
+// MemberBlame is a relation member with the version which added it
+type MemberBlame struct {
+	Type string `xml:"type,attr" json:"type"`
+	Ref  int64  `xml:"ref,attr" json:"ref"`
+	Role string `xml:"role,attr" json:"role"`
+	BlameVersion
+}
+
+func newBlameVersion(version int, changesetID int64, timestamp Time, user *string, userID *int64) BlameVersion {
+	v := BlameVersion{Version: version, ChangesetID: changesetID, Timestamp: timestamp}
+	if user != nil && userID != nil {
+		v.User, v.UserID = *user, *userID
+	}
+	return v
+}
+
+// tagBlamer tracks the versions which set the current tag values
+type tagBlamer struct {
+	tags   Tags
+	values map[string]string
+	blame  map[string]BlameVersion
+}
+
+func (b *tagBlamer) next(tags Tags, v BlameVersion) {
+	values := map[string]string{}
+	blame := map[string]BlameVersion{}
+	for _, t := range tags {
+		values[t.K] = t.V
+		if old, ok := b.values[t.K]; ok && old == t.V {
+			blame[t.K] = b.blame[t.K]
+		} else {
+			blame[t.K] = v
+		}
+	}
+	b.tags, b.values, b.blame = tags, values, blame
+}
+
+func (b *tagBlamer) result() []*TagBlame {
+	result := []*TagBlame{}
+	for _, t := range b.tags {
+		result = append(result, &TagBlame{K: t.K, V: t.V, BlameVersion: b.blame[t.K]})
+	}
+	return result
+}
+
+// sequenceBlamer tracks the versions which inserted items of a list,
+// items kept between versions are aligned the same way as in diffs
+type sequenceBlamer struct {
+	keys  []string
+	blame []BlameVersion
+}
+
+func (b *sequenceBlamer) next(keys []string, v BlameVersion) {
+	blame := make([]BlameVersion, len(keys))
+	removed, added := diffSequences(b.keys, keys)
+	isRemoved := map[int]bool{}
+	for _, i := range removed {
+		isRemoved[i] = true
+	}
+	isAdded := map[int]bool{}
+	for _, j := range added {
+		isAdded[j] = true
+	}
+
+	i := 0
+	for j := range keys {
+		if isAdded[j] {
+			blame[j] = v
+			continue
+		}
+		for isRemoved[i] {
+			i++
+		}
+		blame[j] = b.blame[i]
+		i++
+	}
+	b.keys, b.blame = keys, blame
+}
+
+// NodeBlame returns the blame of the latest version in the node history
+func NodeBlame(history Nodes) *Blame {
+	nodes := append(Nodes{}, history...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Version < nodes[j].Version })
+
+	tags := &tagBlamer{}
+	for _, n := range nodes {
+		tags.next(n.Tags, newBlameVersion(n.Version, n.ChangesetID, n.Timestamp, n.User, n.UserID))
+	}
+	latest := nodes[len(nodes)-1]
+	return &Blame{Type: "node", ID: latest.ID, Version: latest.Version, Tags: tags.result()}
+}
+
+// WayBlame returns the blame of the latest version in the way history
+func WayBlame(history Ways) *Blame {
+	ways := append(Ways{}, history...)
+	sort.Slice(ways, func(i, j int) bool { return ways[i].Version < ways[j].Version })
+
+	tags, nodes := &tagBlamer{}, &sequenceBlamer{}
+	for _, w := range ways {
+		v := newBlameVersion(w.Version, w.ChangesetID, w.Timestamp, w.User, w.UserID)
+		tags.next(w.Tags, v)
+		keys := make([]string, len(w.Nodes))
+		for i, n := range w.Nodes {
+			keys[i] = strconv.FormatInt(n.ID, 10)
+		}
+		nodes.next(keys, v)
+	}
+
+	latest := ways[len(ways)-1]
+	b := &Blame{Type: "way", ID: latest.ID, Version: latest.Version, Tags: tags.result()}
+	for i, n := range latest.Nodes {
+		b.Nodes = append(b.Nodes, &WayNodeBlame{Ref: n.ID, BlameVersion: nodes.blame[i]})
+	}
+	return b
+}
+
+// RelationBlame returns the blame of the latest version in the relation history
+func RelationBlame(history Relations) *Blame {
+	relations := append(Relations{}, history...)
+	sort.Slice(relations, func(i, j int) bool { return relations[i].Version < relations[j].Version })
+
+	tags, members := &tagBlamer{}, &sequenceBlamer{}
+	for _, r := range relations {
+		v := newBlameVersion(r.Version, r.ChangesetID, r.Timestamp, r.User, r.UserID)
+		tags.next(r.Tags, v)
+		keys := make([]string, len(r.Members))
+		for i, m := range r.Members {
+			keys[i] = memberKey(m)
+		}
+		members.next(keys, v)
+	}
+
+	latest := relations[len(relations)-1]
+	b := &Blame{Type: "relation", ID: latest.ID, Version: latest.Version, Tags: tags.result()}
+	for i, m := range latest.Members {
+		b.Members = append(b.Members, &MemberBlame{Type: m.Type, Ref: m.Ref, Role: m.Role, BlameVersion: members.blame[i]})
+	}
+	return b
+}
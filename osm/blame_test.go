@@ -0,0 +1,61 @@
+package osm
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWayBlame(t *testing.T) {
+	alice, bob := "alice", "bob"
+	aliceID, bobID := int64(1), int64(2)
+	history := Ways{
+		{ID: 3, Version: 3, Visible: true, ChangesetID: 30, User: &alice, UserID: &aliceID,
+			Nodes: wayNodes{{ID: 1}, {ID: 4}, {ID: 2}},
+			Tags:  Tags{{K: "highway", V: "primary"}, {K: "name", V: "Main"}}},
+		{ID: 3, Version: 1, Visible: true, ChangesetID: 10, User: &alice, UserID: &aliceID,
+			Nodes: wayNodes{{ID: 1}, {ID: 2}},
+			Tags:  Tags{{K: "highway", V: "primary"}}},
+		{ID: 3, Version: 2, Visible: true, ChangesetID: 20, User: &bob, UserID: &bobID,
+			Nodes: wayNodes{{ID: 1}, {ID: 4}, {ID: 2}},
+			Tags:  Tags{{K: "highway", V: "secondary"}, {K: "name", V: "Main"}}},
+	}
+
+	b := WayBlame(history)
+	if b.Version != 3 || len(b.Tags) != 2 || len(b.Nodes) != 3 {
+		t.Fatalf("incorrect blame: %+v", b)
+	}
+
+	var got []string
+	for _, tb := range b.Tags {
+		got = append(got, tb.K+"="+tb.V+"@"+tb.User)
+	}
+	for _, nb := range b.Nodes {
+		got = append(got, nb.User)
+	}
+	expected := "highway=primary@alice name=Main@bob alice bob alice"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("incorrect blame %q, expected %q", strings.Join(got, " "), expected)
+	}
+	if b.Tags[1].Version != 2 || b.Nodes[1].ChangesetID != 20 {
+		t.Errorf("incorrect versions: %+v %+v", b.Tags[1], b.Nodes[1])
+	}
+
+	if _, err := xml.Marshal(b); err != nil {
+		t.Errorf("marshal error: %v", err)
+	}
+}
+
+func TestRelationBlame(t *testing.T) {
+	history := Relations{
+		{ID: 4, Version: 1, Visible: true, Members: Members{{Type: "way", Ref: 1, Role: "outer"}}},
+		{ID: 4, Version: 2, Visible: false},
+		{ID: 4, Version: 3, Visible: true, Members: Members{
+			{Type: "way", Ref: 1, Role: "outer"}, {Type: "way", Ref: 2, Role: "inner"}}},
+	}
+
+	b := RelationBlame(history)
+	if len(b.Members) != 2 || b.Members[0].Version != 3 || b.Members[1].Version != 3 {
+		t.Errorf("members restored by undeletion aren't blamed on it: %+v %+v", b.Members[0], b.Members[1])
+	}
+}
@@ -0,0 +1,451 @@
+package osm
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Change is an osmChange document with elements grouped by action,
+// see https://wiki.openstreetmap.org/wiki/OsmChange
+type Change struct {
+	Version     float64
+	Generator   string
+	Copyright   string
+	Attribution string
+	License     string
+
+	Create *OSM
+	Modify *OSM
+	Delete *OSM
+}
+
+// NewChange creates change with the default header
+func NewChange() *Change {
+	return &Change{
+		Version:     Version,
+		Generator:   Generator,
+		Copyright:   Copyright,
+		Attribution: Attribution,
+		License:     License,
+	}
+}
+
+// elementKey identifies an element regardless of its version
+type elementKey struct {
+	typ string
+	id  int64
+}
+
+func objectKey(o Object) elementKey {
+	switch o.(type) {
+	case *Node:
+		return elementKey{"node", o.ObjectID()}
+	case *Way:
+		return elementKey{"way", o.ObjectID()}
+	case *Relation:
+		return elementKey{"relation", o.ObjectID()}
+	}
+	return elementKey{"changeset", o.ObjectID()}
+}
+
+// actions returns action names with their elements in the document order
+func (c *Change) actions() []struct {
+	name string
+	osm  **OSM
+} {
+	return []struct {
+		name string
+		osm  **OSM
+	}{
+		{ActionCreate, &c.Create},
+		{ActionModify, &c.Modify},
+		{ActionDelete, &c.Delete},
+	}
+}
+
+// changeElement is an element of osmChange with its action
+type changeElement struct {
+	action string
+	obj    Object
+}
+
+// elements returns the elements in the order of osm.org changeset downloads:
+// by timestamp, version, type and id
+func (c *Change) elements() []changeElement {
+	var elements []changeElement
+	for _, a := range c.actions() {
+		for _, obj := range (*a.osm).Objects() {
+			elements = append(elements, changeElement{a.name, obj})
+		}
+	}
+	sort.SliceStable(elements, func(i, j int) bool {
+		ti, vi, ki, idi := changeOrder(elements[i].obj)
+		tj, vj, kj, idj := changeOrder(elements[j].obj)
+		switch {
+		case !ti.Equal(tj):
+			return ti.Before(tj)
+		case vi != vj:
+			return vi < vj
+		case ki != kj:
+			return ki < kj
+		}
+		return idi < idj
+	})
+	return elements
+}
+
+// changeOrder returns timestamp, version, type rank and id of the element
+func changeOrder(o Object) (time.Time, int, int, int64) {
+	switch o := o.(type) {
+	case *Node:
+		return time.Time(o.Timestamp), o.Version, 0, o.ID
+	case *Way:
+		return time.Time(o.Timestamp), o.Version, 1, o.ID
+	case *Relation:
+		return time.Time(o.Timestamp), o.Version, 2, o.ID
+	case *Changeset:
+		return time.Time(o.CreatedAt), 0, 3, o.ID
+	}
+	return time.Time{}, 0, 0, 0
+}
+
+func (c *Change) header() *OSM {
+	return &OSM{
+		Version:     c.Version,
+		Generator:   c.Generator,
+		Copyright:   c.Copyright,
+		Attribution: c.Attribution,
+		License:     c.License,
+	}
+}
+
+// WriteXML writes the change byte-for-byte the same as osm.org changeset
+// downloads: every element is in its own action block, see elements.
+func (c *Change) WriteXML(w io.Writer) error {
+	x := NewXMLWriter(w)
+	x.root = "osmChange"
+	x.header = c.header()
+	x.indent = []byte{' '}
+	for _, e := range c.elements() {
+		if err := x.start(); err != nil {
+			return err
+		}
+		x.buf = append(x.openOSM(), " <"+e.action+">\n"...)
+		if err := writeObject(x, e.obj); err != nil {
+			return err
+		}
+		x.buf = append(x.buf, " </"+e.action+">\n"...)
+	}
+	return x.Close()
+}
+
+// MarshalXML encodes the change as osmChange document with the blocks of
+// WriteXML. Use WriteXML for the osm.org declaration and indentation.
+func (c Change) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = c.header().startElement()
+	start.Name.Local = "osmChange"
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, el := range c.elements() {
+		block := xml.StartElement{Name: xml.Name{Local: el.action}}
+		if err := e.EncodeToken(block); err != nil {
+			return err
+		}
+		o := &OSM{}
+		if err := writeObject(o, el.obj); err != nil {
+			return err
+		}
+		if err := o.marshalInnerElementsXML(e); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(block.End()); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML decodes osmChange document. Several blocks of the same
+// action are joined, elements of delete blocks are marked as not visible.
+func (c *Change) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if start.Name.Local != "osmChange" {
+		return fmt.Errorf("osm: expected <osmChange> element, got <%v>", start.Name.Local)
+	}
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "version":
+			v, err := strconv.ParseFloat(a.Value, 64)
+			if err != nil {
+				return err
+			}
+			c.Version = v
+		case "generator":
+			c.Generator = a.Value
+		case "copyright":
+			c.Copyright = a.Value
+		case "attribution":
+			c.Attribution = a.Value
+		case "license":
+			c.License = a.Value
+		}
+	}
+
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			var target **OSM
+			for _, a := range c.actions() {
+				if a.name == t.Name.Local {
+					target = a.osm
+				}
+			}
+			if target == nil {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if *target == nil {
+				*target = &OSM{}
+			}
+			if err := decodeActionXML(d, *target, t.Name.Local == ActionDelete); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decodeActionXML decodes elements of an action block into o
+func decodeActionXML(d *xml.Decoder, o *OSM, deleted bool) error {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			var obj Object
+			switch t.Name.Local {
+			case "node":
+				obj = &Node{Visible: true}
+			case "way":
+				obj = &Way{Visible: true}
+			case "relation":
+				obj = &Relation{Visible: true}
+			case "changeset":
+				obj = &Changeset{}
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.DecodeElement(obj, &t); err != nil {
+				return err
+			}
+			if deleted {
+				setVisible(obj, false)
+			}
+			if err := writeObject(o, obj); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type changeJSON struct {
+	Version     float64         `json:"version,omitempty"`
+	Generator   string          `json:"generator,omitempty"`
+	Copyright   string          `json:"copyright,omitempty"`
+	Attribution string          `json:"attribution,omitempty"`
+	License     string          `json:"license,omitempty"`
+	Create      json.RawMessage `json:"create,omitempty"`
+	Modify      json.RawMessage `json:"modify,omitempty"`
+	Delete      json.RawMessage `json:"delete,omitempty"`
+}
+
+// MarshalJSON encodes the change with the osm json header
+// and create, modify and delete element arrays.
+func (c Change) MarshalJSON() ([]byte, error) {
+	s := changeJSON{
+		Version:     c.Version,
+		Generator:   c.Generator,
+		Copyright:   c.Copyright,
+		Attribution: c.Attribution,
+		License:     c.License,
+	}
+	for i, raw := range []*json.RawMessage{&s.Create, &s.Modify, &s.Delete} {
+		objects := (*c.actions()[i].osm).Objects()
+		if len(objects) == 0 {
+			continue
+		}
+		b, err := json.Marshal(objects)
+		if err != nil {
+			return nil, err
+		}
+		*raw = b
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON decodes the change encoded by MarshalJSON
+func (c *Change) UnmarshalJSON(b []byte) error {
+	var s changeJSON
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	c.Version = s.Version
+	c.Generator = s.Generator
+	c.Copyright = s.Copyright
+	c.Attribution = s.Attribution
+	c.License = s.License
+
+	for i, raw := range []json.RawMessage{s.Create, s.Modify, s.Delete} {
+		if len(raw) == 0 {
+			continue
+		}
+		o, err := unmarshalObjectsJSON(raw)
+		if err != nil {
+			return err
+		}
+		*c.actions()[i].osm = o
+	}
+	return nil
+}
+
+// unmarshalObjectsJSON decodes json array of elements of any type
+func unmarshalObjectsJSON(b []byte) (*OSM, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(b, &raws); err != nil {
+		return nil, err
+	}
+
+	o := &OSM{}
+	for _, raw := range raws {
+		var t struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, err
+		}
+
+		var obj Object
+		switch t.Type {
+		case "node":
+			obj = &Node{}
+		case "way":
+			obj = &Way{}
+		case "relation":
+			obj = &Relation{}
+		case "changeset":
+			obj = &Changeset{}
+		default:
+			return nil, fmt.Errorf("osm: unknown element type %q", t.Type)
+		}
+		if err := json.Unmarshal(raw, obj); err != nil {
+			return nil, err
+		}
+		if err := writeObject(o, obj); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Merge appends a later change to c, so c contains the net result
+// of both changes. An element created and then deleted disappears,
+// created and then modified one stays created with the latest version
+// and modified and then deleted one becomes deleted.
+func (c *Change) Merge(later *Change) {
+	actions := map[elementKey]string{}
+	objects := map[elementKey]Object{}
+	var order []elementKey
+	for _, change := range []*Change{c, later} {
+		for _, a := range change.actions() {
+			for _, obj := range (*a.osm).Objects() {
+				k := objectKey(obj)
+				prev, ok := actions[k]
+				if !ok {
+					order = append(order, k)
+				}
+				actions[k] = mergeAction(prev, a.name)
+				objects[k] = obj
+			}
+		}
+	}
+
+	c.Create, c.Modify, c.Delete = &OSM{}, &OSM{}, &OSM{}
+	for _, k := range order {
+		for _, a := range c.actions() {
+			if a.name == actions[k] {
+				writeObject(*a.osm, objects[k])
+			}
+		}
+	}
+}
+
+// mergeAction returns the action equal to prev followed by next,
+// empty action means there is no change at all
+func mergeAction(prev, next string) string {
+	switch {
+	case prev == "":
+		return next
+	case prev == ActionCreate && next == ActionDelete:
+		return ""
+	case prev == ActionCreate:
+		return ActionCreate
+	case prev == ActionDelete && next != ActionDelete:
+		return ActionModify
+	}
+	return next
+}
+
+// Apply applies the change to o. Created and modified elements replace
+// the current versions or are added, deleted elements are removed.
+func (c *Change) Apply(o *OSM) {
+	objects := map[elementKey]Object{}
+	var order []elementKey
+	set := func(obj Object) {
+		k := objectKey(obj)
+		if _, ok := objects[k]; !ok {
+			order = append(order, k)
+		}
+		objects[k] = obj
+	}
+
+	for _, obj := range o.Objects() {
+		set(obj)
+	}
+	for _, obj := range c.Create.Objects() {
+		set(obj)
+	}
+	for _, obj := range c.Modify.Objects() {
+		set(obj)
+	}
+	for _, obj := range c.Delete.Objects() {
+		delete(objects, objectKey(obj))
+	}
+
+	o.Nodes, o.Ways, o.Relations, o.Changesets = nil, nil, nil, nil
+	for _, k := range order {
+		if obj, ok := objects[k]; ok {
+			writeObject(o, obj)
+		}
+	}
+}
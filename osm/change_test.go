@@ -0,0 +1,135 @@
+package osm
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func testChange() *Change {
+	o := testOSM()
+	c := NewChange()
+	c.Create = &OSM{Nodes: Nodes{o.Nodes[0]}, Ways: o.Ways}
+	c.Modify = &OSM{Relations: o.Relations}
+	c.Delete = &OSM{Nodes: Nodes{o.Nodes[1]}}
+	c.Delete.Nodes[0].Visible = false
+	return c
+}
+
+func TestChangeRoundTrip(t *testing.T) {
+	cases := []struct {
+		name      string
+		marshal   func(interface{}) ([]byte, error)
+		unmarshal func([]byte, interface{}) error
+	}{
+		{name: "xml", marshal: xml.Marshal, unmarshal: xml.Unmarshal},
+		{name: "json", marshal: json.Marshal, unmarshal: json.Unmarshal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := testChange()
+			data, err := tc.marshal(c)
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+
+			decoded := &Change{}
+			if err := tc.unmarshal(data, decoded); err != nil {
+				t.Fatalf("unmarshal error: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, c) {
+				t.Errorf("incorrect decoded change: %s", data)
+			}
+
+			again, err := tc.marshal(decoded)
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+			if string(again) != string(data) {
+				t.Errorf("incorrect output:\n%s\nexpected:\n%s", again, data)
+			}
+		})
+	}
+}
+
+// TestChangeWriteXML round-trips a changeset download in the osm.org format
+func TestChangeWriteXML(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/change.osc")
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	c := &Change{}
+	if err := xml.Unmarshal(data, c); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if len(c.Create.Objects()) != 3 || len(c.Modify.Objects()) != 2 || len(c.Delete.Objects()) != 2 {
+		t.Fatalf("incorrect decoded change: %+v %+v %+v", c.Create, c.Modify, c.Delete)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := c.WriteXML(buf); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if v := buf.String(); v != string(data) {
+		t.Errorf("incorrect output:\n%v\nexpected:\n%v", v, string(data))
+	}
+
+	buf.Reset()
+	if err := NewChange().WriteXML(buf); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	expected := xmlHeader + `<osmChange version="0.6" generator="` + Generator + `" copyright="` + Copyright +
+		`" attribution="` + Attribution + `" license="` + License + `"/>` + "\n"
+	if v := buf.String(); v != expected {
+		t.Errorf("incorrect empty change:\n%v\nexpected:\n%v", v, expected)
+	}
+}
+
+func TestChangeMerge(t *testing.T) {
+	o := testOSM()
+	c := testChange()
+	later := &Change{
+		Modify: &OSM{Ways: o.Ways},
+		Delete: &OSM{Nodes: Nodes{o.Nodes[0]}, Relations: o.Relations},
+		Create: &OSM{Nodes: Nodes{o.Nodes[1]}},
+	}
+	c.Merge(later)
+
+	if len(c.Create.Nodes) != 0 || len(c.Create.Ways) != 1 {
+		t.Errorf("incorrect create: %+v", c.Create)
+	}
+	if len(c.Modify.Nodes) != 1 || c.Modify.Nodes[0].ID != 2 {
+		t.Errorf("incorrect modify: %+v", c.Modify)
+	}
+	if len(c.Delete.Relations) != 1 || len(c.Delete.Nodes) != 0 {
+		t.Errorf("incorrect delete: %+v", c.Delete)
+	}
+}
+
+func TestChangeApply(t *testing.T) {
+	o := testOSM()
+	modified := *o.Ways[0]
+	modified.Version = 2
+	node := &Node{ID: 5, Visible: true, Version: 1}
+
+	c := &Change{
+		Create: &OSM{Nodes: Nodes{node}},
+		Modify: &OSM{Ways: Ways{&modified}},
+		Delete: &OSM{Relations: o.Relations},
+	}
+	c.Apply(o)
+
+	if len(o.Nodes) != 3 || o.Nodes[2] != node {
+		t.Errorf("incorrect nodes: %v", o.Nodes)
+	}
+	if len(o.Ways) != 1 || o.Ways[0].Version != 2 {
+		t.Errorf("incorrect ways: %v", o.Ways)
+	}
+	if len(o.Relations) != 0 {
+		t.Errorf("incorrect relations: %v", o.Relations)
+	}
+}
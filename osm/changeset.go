@@ -14,6 +14,11 @@ func (x xmlNameJSONTypeCS) MarshalJSON() ([]byte, error) {
 	return []byte(`"changeset"`), nil
 }
 
+// UnmarshalJSON ignores the type, it's known from the struct type
+func (x *xmlNameJSONTypeCS) UnmarshalJSON(b []byte) error {
+	return nil
+}
+
 // A Changeset is a set of metadata around a set of osm changes.
 type Changeset struct {
 	XMLName       xmlNameJSONTypeCS    `xml:"changeset" json:"type"`
@@ -0,0 +1,281 @@
+package osm
+
+import (
+	"encoding/xml"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Actions of diff entries
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+	DiffMoved   = "moved"
+)
+
+// earthRadius is the mean earth radius in meters
+const earthRadius = 6371008.8
+
+// maxDiffCells limits the memory used to diff node and member lists,
+// longer changed parts are reported as removed and added as a whole
+const maxDiffCells = 1 << 22
+
+// Diff is the difference between two versions of an element
+type Diff struct {
+	XMLName  xml.Name       `xml:"diff" json:"-"`
+	Type     string         `xml:"type,attr" json:"type"`
+	ID       int64          `xml:"id,attr" json:"id"`
+	From     int            `xml:"from,attr" json:"from"`
+	To       int            `xml:"to,attr" json:"to"`
+	Visible  *VisibleDiff   `xml:"visible" json:"visible,omitempty"`
+	Location *LocationDiff  `xml:"location" json:"location,omitempty"`
+	Tags     []*TagDiff     `xml:"tag" json:"tags,omitempty"`
+	Nodes    []*WayNodeDiff `xml:"nd" json:"nodes,omitempty"`
+	Members  []*MemberDiff  `xml:"member" json:"members,omitempty"`
+}
+
+// VisibleDiff is a change of the element visibility
+type VisibleDiff struct {
+	From bool `xml:"from,attr" json:"from"`
+	To   bool `xml:"to,attr" json:"to"`
+}
+
+// LocationDiff is a move of the node, distance is in meters
+type LocationDiff struct {
+	FromLat  float64 `xml:"from_lat,attr" json:"from_lat"`
+	FromLon  float64 `xml:"from_lon,attr" json:"from_lon"`
+	ToLat    float64 `xml:"to_lat,attr" json:"to_lat"`
+	ToLon    float64 `xml:"to_lon,attr" json:"to_lon"`
+	Distance float64 `xml:"distance,attr" json:"distance"`
+}
+
+// TagDiff is an added, removed or changed tag
+type TagDiff struct {
+	Action string  `xml:"action,attr" json:"action"`
+	K      string  `xml:"k,attr" json:"k"`
+	From   *string `xml:"from,attr,omitempty" json:"from,omitempty"`
+	To     *string `xml:"to,attr,omitempty" json:"to,omitempty"`
+}
+
+// WayNodeDiff is an inserted or removed way node,
+// From and To are its positions in the versions
+type WayNodeDiff struct {
+	Action string `xml:"action,attr" json:"action"`
+	Ref    int64  `xml:"ref,attr" json:"ref"`
+	From   *int   `xml:"from,attr,omitempty" json:"from,omitempty"`
+	To     *int   `xml:"to,attr,omitempty" json:"to,omitempty"`
+}
+
+// MemberDiff is an added, removed or moved relation member,
+// From and To are its positions in the versions
+type MemberDiff struct {
+	Action string `xml:"action,attr" json:"action"`
+	Type   string `xml:"type,attr" json:"type"`
+	Ref    int64  `xml:"ref,attr" json:"ref"`
+	Role   string `xml:"role,attr" json:"role"`
+	From   *int   `xml:"from,attr,omitempty" json:"from,omitempty"`
+	To     *int   `xml:"to,attr,omitempty" json:"to,omitempty"`
+}
+
+// IsEmpty returns true if the versions are the same
+func (d *Diff) IsEmpty() bool {
+	return d.Visible == nil && d.Location == nil && len(d.Tags) == 0 && len(d.Nodes) == 0 && len(d.Members) == 0
+}
+
+func newDiff(elementType string, id int64, from, to int, fromVisible, toVisible bool, fromTags, toTags Tags) *Diff {
+	d := &Diff{Type: elementType, ID: id, From: from, To: to, Tags: diffTags(fromTags, toTags)}
+	if fromVisible != toVisible {
+		d.Visible = &VisibleDiff{From: fromVisible, To: toVisible}
+	}
+	return d
+}
+
+// NodeDiff returns the difference between two versions of the node
+func NodeDiff(from, to *Node) *Diff {
+	d := newDiff("node", to.ID, from.Version, to.Version, from.Visible, to.Visible, from.Tags, to.Tags)
+	if from.Lat != nil && from.Lon != nil && to.Lat != nil && to.Lon != nil &&
+		(*from.Lat != *to.Lat || *from.Lon != *to.Lon) {
+		d.Location = &LocationDiff{
+			FromLat:  *from.Lat,
+			FromLon:  *from.Lon,
+			ToLat:    *to.Lat,
+			ToLon:    *to.Lon,
+			Distance: math.Round(distance(*from.Lat, *from.Lon, *to.Lat, *to.Lon)*100) / 100,
+		}
+	}
+	return d
+}
+
+// WayDiff returns the difference between two versions of the way
+func WayDiff(from, to *Way) *Diff {
+	d := newDiff("way", to.ID, from.Version, to.Version, from.Visible, to.Visible, from.Tags, to.Tags)
+	fromRefs := make([]string, len(from.Nodes))
+	for i, n := range from.Nodes {
+		fromRefs[i] = strconv.FormatInt(n.ID, 10)
+	}
+	toRefs := make([]string, len(to.Nodes))
+	for i, n := range to.Nodes {
+		toRefs[i] = strconv.FormatInt(n.ID, 10)
+	}
+
+	removed, added := diffSequences(fromRefs, toRefs)
+	for _, i := range removed {
+		d.Nodes = append(d.Nodes, &WayNodeDiff{Action: DiffRemoved, Ref: from.Nodes[i].ID, From: intPtr(i)})
+	}
+	for _, i := range added {
+		d.Nodes = append(d.Nodes, &WayNodeDiff{Action: DiffAdded, Ref: to.Nodes[i].ID, To: intPtr(i)})
+	}
+	return d
+}
+
+// RelationDiff returns the difference between two versions of the relation.
+// Members which are removed at one position and added at another are moved.
+func RelationDiff(from, to *Relation) *Diff {
+	d := newDiff("relation", to.ID, from.Version, to.Version, from.Visible, to.Visible, from.Tags, to.Tags)
+	fromKeys := make([]string, len(from.Members))
+	for i, m := range from.Members {
+		fromKeys[i] = memberKey(m)
+	}
+	toKeys := make([]string, len(to.Members))
+	for i, m := range to.Members {
+		toKeys[i] = memberKey(m)
+	}
+
+	removed, added := diffSequences(fromKeys, toKeys)
+	removedByKey := map[string][]int{}
+	for _, i := range removed {
+		removedByKey[fromKeys[i]] = append(removedByKey[fromKeys[i]], i)
+	}
+	moved := map[int]bool{}
+	var addedDiffs []*MemberDiff
+	for _, i := range added {
+		m := to.Members[i]
+		md := &MemberDiff{Action: DiffAdded, Type: m.Type, Ref: m.Ref, Role: m.Role, To: intPtr(i)}
+		if positions := removedByKey[toKeys[i]]; len(positions) != 0 {
+			md.Action, md.From = DiffMoved, intPtr(positions[0])
+			moved[positions[0]] = true
+			removedByKey[toKeys[i]] = positions[1:]
+		}
+		addedDiffs = append(addedDiffs, md)
+	}
+	for _, i := range removed {
+		if !moved[i] {
+			m := from.Members[i]
+			d.Members = append(d.Members, &MemberDiff{Action: DiffRemoved, Type: m.Type, Ref: m.Ref, Role: m.Role, From: intPtr(i)})
+		}
+	}
+	d.Members = append(d.Members, addedDiffs...)
+	return d
+}
+
+func memberKey(m Member) string {
+	return m.Type + "/" + strconv.FormatInt(m.Ref, 10) + "/" + m.Role
+}
+
+// diffTags returns tag changes sorted by key
+func diffTags(from, to Tags) []*TagDiff {
+	fromValues := map[string]string{}
+	for _, t := range from {
+		fromValues[t.K] = t.V
+	}
+	toValues := map[string]string{}
+	for _, t := range to {
+		toValues[t.K] = t.V
+	}
+
+	var diffs []*TagDiff
+	for k, v := range fromValues {
+		v := v
+		if newV, ok := toValues[k]; !ok {
+			diffs = append(diffs, &TagDiff{Action: DiffRemoved, K: k, From: &v})
+		} else if newV != v {
+			newV := newV
+			diffs = append(diffs, &TagDiff{Action: DiffChanged, K: k, From: &v, To: &newV})
+		}
+	}
+	for k, v := range toValues {
+		v := v
+		if _, ok := fromValues[k]; !ok {
+			diffs = append(diffs, &TagDiff{Action: DiffAdded, K: k, To: &v})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].K < diffs[j].K })
+	return diffs
+}
+
+// diffSequences returns positions of items removed from a and added to b,
+// the items of the longest common subsequence are kept
+func diffSequences(a, b []string) (removed, added []int) {
+	// common prefix and suffix are kept, usually only a small part changes
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+	}
+
+	n, m := endA-start, endB-start
+	if n*m > maxDiffCells {
+		for i := start; i < endA; i++ {
+			removed = append(removed, i)
+		}
+		for j := start; j < endB; j++ {
+			added = append(added, j)
+		}
+		return removed, added
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([]int32, (n+1)*(m+1))
+	at := func(i, j int) int32 { return lcs[i*(m+1)+j] }
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[start+i] == b[start+j] {
+				lcs[i*(m+1)+j] = at(i+1, j+1) + 1
+			} else if at(i+1, j) >= at(i, j+1) {
+				lcs[i*(m+1)+j] = at(i+1, j)
+			} else {
+				lcs[i*(m+1)+j] = at(i, j+1)
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[start+i] == b[start+j]:
+			i++
+			j++
+		case at(i+1, j) >= at(i, j+1):
+			removed = append(removed, start+i)
+			i++
+		default:
+			added = append(added, start+j)
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		removed = append(removed, start+i)
+	}
+	for ; j < m; j++ {
+		added = append(added, start+j)
+	}
+	return removed, added
+}
+
+// distance returns the great-circle distance between the points in meters
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dPhi, dLambda := (lat2-lat1)*math.Pi/180, (lon2-lon1)*math.Pi/180
+	h := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,80 @@
+package osm
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestNodeDiff(t *testing.T) {
+	lat1, lon1, lat2, lon2 := 51.5, -0.1, 51.501, -0.1
+	from := &Node{ID: 1, Version: 1, Visible: true, Lat: &lat1, Lon: &lon1,
+		Tags: Tags{{K: "amenity", V: "cafe"}, {K: "name", V: "Old"}}}
+	to := &Node{ID: 1, Version: 3, Visible: true, Lat: &lat2, Lon: &lon2,
+		Tags: Tags{{K: "name", V: "New"}, {K: "wifi", V: "yes"}}}
+
+	d := NodeDiff(from, to)
+	if d.From != 1 || d.To != 3 || d.Visible != nil {
+		t.Errorf("incorrect diff: %+v", d)
+	}
+	if d.Location == nil || d.Location.Distance != 111.2 {
+		t.Errorf("incorrect location: %+v", d.Location)
+	}
+
+	var actions []string
+	for _, td := range d.Tags {
+		actions = append(actions, td.K+" "+td.Action)
+	}
+	expected := []string{"amenity removed", "name changed", "wifi added"}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("incorrect tags %v, expected %v", actions, expected)
+	}
+
+	deleted := &Node{ID: 1, Version: 4}
+	if d := NodeDiff(to, deleted); d.Visible == nil || d.Location != nil || len(d.Tags) != 2 {
+		t.Errorf("incorrect diff of deleted node: %+v", d)
+	}
+	if d := NodeDiff(to, to); !d.IsEmpty() {
+		t.Errorf("diff of the same version isn't empty: %+v", d)
+	}
+}
+
+func TestWayDiff(t *testing.T) {
+	from := &Way{ID: 3, Version: 1, Visible: true, Nodes: wayNodes{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 1}}}
+	to := &Way{ID: 3, Version: 2, Visible: true, Nodes: wayNodes{{ID: 1}, {ID: 4}, {ID: 3}, {ID: 5}, {ID: 1}}}
+
+	d := WayDiff(from, to)
+	expected := []*WayNodeDiff{
+		{Action: DiffRemoved, Ref: 2, From: intPtr(1)},
+		{Action: DiffAdded, Ref: 4, To: intPtr(1)},
+		{Action: DiffAdded, Ref: 5, To: intPtr(3)},
+	}
+	if !reflect.DeepEqual(d.Nodes, expected) {
+		data, _ := xml.Marshal(d)
+		t.Errorf("incorrect nodes: %s", data)
+	}
+}
+
+func TestRelationDiff(t *testing.T) {
+	from := &Relation{ID: 4, Version: 1, Visible: true, Members: Members{
+		{Type: "way", Ref: 1, Role: "outer"},
+		{Type: "way", Ref: 2, Role: "outer"},
+		{Type: "node", Ref: 3, Role: "label"},
+	}}
+	to := &Relation{ID: 4, Version: 2, Visible: true, Members: Members{
+		{Type: "way", Ref: 2, Role: "outer"},
+		{Type: "way", Ref: 1, Role: "outer"},
+		{Type: "way", Ref: 5, Role: "inner"},
+	}}
+
+	d := RelationDiff(from, to)
+	expected := []*MemberDiff{
+		{Action: DiffRemoved, Type: "node", Ref: 3, Role: "label", From: intPtr(2)},
+		{Action: DiffMoved, Type: "way", Ref: 1, Role: "outer", From: intPtr(0), To: intPtr(1)},
+		{Action: DiffAdded, Type: "way", Ref: 5, Role: "inner", To: intPtr(2)},
+	}
+	if !reflect.DeepEqual(d.Members, expected) {
+		data, _ := xml.Marshal(d)
+		t.Errorf("incorrect members: %s", data)
+	}
+}
@@ -0,0 +1,378 @@
+package osm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// areaKeys are keys which make a closed way an area,
+// see https://wiki.openstreetmap.org/wiki/Key:area
+var areaKeys = map[string]bool{
+	"aeroway":  true,
+	"amenity":  true,
+	"building": true,
+	"historic": true,
+	"landuse":  true,
+	"leisure":  true,
+	"man_made": true,
+	"military": true,
+	"natural":  true,
+	"office":   true,
+	"place":    true,
+	"shop":     true,
+	"sport":    true,
+	"tourism":  true,
+	"water":    true,
+}
+
+// notAreaValues are values of area keys which are still linear
+var notAreaValues = map[string]bool{
+	"natural=coastline": true,
+	"natural=cliff":     true,
+	"natural=ridge":     true,
+	"natural=tree_row":  true,
+	"man_made=pipeline": true,
+	"man_made=cutline":  true,
+}
+
+// FeatureCollection is GeoJSON feature collection
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// Feature is GeoJSON feature made of osm element
+type Feature struct {
+	Type       string            `json:"type"`
+	ID         string            `json:"id"`
+	Geometry   *Geometry         `json:"geometry"`
+	Properties map[string]string `json:"properties"`
+	Meta       *FeatureMeta      `json:"meta"`
+}
+
+// FeatureMeta contains osm metadata of the feature
+type FeatureMeta struct {
+	Type        string  `json:"type"`
+	ID          int64   `json:"id"`
+	Version     int     `json:"version"`
+	ChangesetID int64   `json:"changeset"`
+	Timestamp   Time    `json:"timestamp"`
+	User        *string `json:"user,omitempty"`
+	UserID      *int64  `json:"uid,omitempty"`
+}
+
+// Geometry is GeoJSON geometry
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// Point is [lon, lat] position
+type Point [2]float64
+
+// Ring is closed line
+type Ring []Point
+
+// Polygon is an outer ring followed by inner rings
+type Polygon []Ring
+
+// GeoJSON converts osm elements to GeoJSON features. Nodes become points,
+// ways become line strings or polygons when they are closed areas and
+// multipolygon and boundary relations become multipolygons assembled
+// from member ways. Untagged nodes and ways which are only parts of other
+// features are skipped.
+func (o *OSM) GeoJSON() *FeatureCollection {
+	fc := &FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []*Feature{},
+	}
+
+	nodes := make(map[int64]*Node, len(o.Nodes))
+	for _, n := range o.Nodes {
+		nodes[n.ID] = n
+	}
+	ways := make(map[int64]*Way, len(o.Ways))
+	usedNodes := map[int64]bool{}
+	for _, w := range o.Ways {
+		ways[w.ID] = w
+		for _, wn := range w.Nodes {
+			usedNodes[wn.ID] = true
+		}
+	}
+
+	usedWays := map[int64]bool{}
+	var relations []*Feature
+	for _, r := range o.Relations {
+		if !r.IsArea() {
+			continue
+		}
+		polygons := assemblePolygons(r, ways, nodes)
+		if len(polygons) == 0 {
+			continue
+		}
+		for _, m := range r.Members {
+			if m.Type == "way" {
+				usedWays[m.Ref] = true
+			}
+		}
+		relations = append(relations, newFeature("relation", r.ID, r.Version, r.ChangesetID,
+			r.Timestamp, r.User, r.UserID, r.Tags, &Geometry{Type: "MultiPolygon", Coordinates: polygons}))
+	}
+
+	for _, n := range o.Nodes {
+		if !n.Visible || n.Lat == nil || n.Lon == nil {
+			continue
+		}
+		if usedNodes[n.ID] && len(n.Tags) == 0 {
+			continue
+		}
+		fc.Features = append(fc.Features, newFeature("node", n.ID, n.Version, n.ChangesetID,
+			n.Timestamp, n.User, n.UserID, n.Tags, &Geometry{Type: "Point", Coordinates: Point{*n.Lon, *n.Lat}}))
+	}
+
+	for _, w := range o.Ways {
+		if !w.Visible || (usedWays[w.ID] && len(w.Tags) == 0) {
+			continue
+		}
+		line, ok := wayLine(w, nodes)
+		if !ok || len(line) < 2 {
+			continue
+		}
+		geometry := &Geometry{Type: "LineString", Coordinates: line}
+		if w.IsArea() && len(line) >= 4 {
+			geometry = &Geometry{Type: "Polygon", Coordinates: Polygon{orient(Ring(line), true)}}
+		}
+		fc.Features = append(fc.Features, newFeature("way", w.ID, w.Version, w.ChangesetID,
+			w.Timestamp, w.User, w.UserID, w.Tags, geometry))
+	}
+
+	fc.Features = append(fc.Features, relations...)
+	return fc
+}
+
+// IsClosed checks that the way starts and ends with the same node
+func (w *Way) IsClosed() bool {
+	return len(w.Nodes) > 2 && w.Nodes[0].ID == w.Nodes[len(w.Nodes)-1].ID
+}
+
+// IsArea checks that the way is closed and its tags describe an area
+func (w *Way) IsArea() bool {
+	if !w.IsClosed() {
+		return false
+	}
+	isArea := false
+	for _, t := range w.Tags {
+		if t.K == "area" {
+			return t.V != "no"
+		}
+		if areaKeys[t.K] && t.V != "no" && !notAreaValues[t.K+"="+t.V] {
+			isArea = true
+		}
+	}
+	return isArea
+}
+
+// IsArea checks that the relation is multipolygon or boundary
+func (r *Relation) IsArea() bool {
+	for _, t := range r.Tags {
+		if t.K == "type" {
+			return t.V == "multipolygon" || t.V == "boundary"
+		}
+	}
+	return false
+}
+
+func newFeature(typ string, id int64, version int, changeset int64, timestamp Time,
+	user *string, userID *int64, tags Tags, geometry *Geometry) *Feature {
+	properties := make(map[string]string, len(tags))
+	for _, t := range tags {
+		properties[t.K] = t.V
+	}
+	return &Feature{
+		Type:       "Feature",
+		ID:         fmt.Sprintf("%v/%v", typ, id),
+		Geometry:   geometry,
+		Properties: properties,
+		Meta: &FeatureMeta{
+			Type:        typ,
+			ID:          id,
+			Version:     version,
+			ChangesetID: changeset,
+			Timestamp:   timestamp,
+			User:        user,
+			UserID:      userID,
+		},
+	}
+}
+
+// wayLine returns way coordinates, false is returned if some node is missing
+func wayLine(w *Way, nodes map[int64]*Node) ([]Point, bool) {
+	line := make([]Point, 0, len(w.Nodes))
+	for _, wn := range w.Nodes {
+		n, ok := nodes[wn.ID]
+		if !ok || n.Lat == nil || n.Lon == nil {
+			return nil, false
+		}
+		line = append(line, Point{*n.Lon, *n.Lat})
+	}
+	return line, true
+}
+
+// Polygons returns the outline of the relation assembled from its member ways,
+// the ways and their nodes have to be in the lists
+func (r *Relation) Polygons(ways Ways, nodes Nodes) []Polygon {
+	wayMap := make(map[int64]*Way, len(ways))
+	for _, w := range ways {
+		wayMap[w.ID] = w
+	}
+	nodeMap := make(map[int64]*Node, len(nodes))
+	for _, n := range nodes {
+		nodeMap[n.ID] = n
+	}
+	return assemblePolygons(r, wayMap, nodeMap)
+}
+
+// assemblePolygons joins outer and inner member ways of the relation
+// into closed rings and puts every inner ring into the outer ring containing it.
+// Rings which can't be closed are skipped.
+func assemblePolygons(r *Relation, ways map[int64]*Way, nodes map[int64]*Node) []Polygon {
+	var outerLines, innerLines [][]Point
+	for _, m := range r.Members {
+		if m.Type != "way" {
+			continue
+		}
+		w, ok := ways[m.Ref]
+		if !ok {
+			continue
+		}
+		line, ok := wayLine(w, nodes)
+		if !ok || len(line) < 2 {
+			continue
+		}
+		if m.Role == "inner" {
+			innerLines = append(innerLines, line)
+			continue
+		}
+		outerLines = append(outerLines, line)
+	}
+
+	var polygons []Polygon
+	for _, outer := range joinRings(outerLines) {
+		polygons = append(polygons, Polygon{orient(outer, true)})
+	}
+	for _, inner := range joinRings(innerLines) {
+		// the smallest outer ring containing the inner one is its owner
+		owner := -1
+		for i := range polygons {
+			if !polygons[i][0].Contains(inner[0]) {
+				continue
+			}
+			if owner == -1 || math.Abs(polygons[i][0].Area()) < math.Abs(polygons[owner][0].Area()) {
+				owner = i
+			}
+		}
+		if owner != -1 {
+			polygons[owner] = append(polygons[owner], orient(inner, false))
+		}
+	}
+	return polygons
+}
+
+// joinRings joins lines by their end points into closed rings
+func joinRings(lines [][]Point) []Ring {
+	var rings []Ring
+	used := make([]bool, len(lines))
+	for i := range lines {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		ring := append(Ring{}, lines[i]...)
+
+		for ring[0] != ring[len(ring)-1] {
+			joined := false
+			for j := range lines {
+				if used[j] {
+					continue
+				}
+				line := lines[j]
+				last := ring[len(ring)-1]
+				switch last {
+				case line[0]:
+					ring = append(ring, line[1:]...)
+				case line[len(line)-1]:
+					for k := len(line) - 2; k >= 0; k-- {
+						ring = append(ring, line[k])
+					}
+				default:
+					continue
+				}
+				used[j] = true
+				joined = true
+				break
+			}
+			if !joined {
+				break
+			}
+		}
+
+		if ring[0] == ring[len(ring)-1] && len(ring) >= 4 {
+			rings = append(rings, ring)
+		}
+	}
+	return rings
+}
+
+// Area returns signed area of the ring, it's positive for counterclockwise rings
+func (r Ring) Area() float64 {
+	var area float64
+	for i := 0; i+1 < len(r); i++ {
+		area += r[i][0]*r[i+1][1] - r[i+1][0]*r[i][1]
+	}
+	return area / 2
+}
+
+// Contains checks that the point is inside the ring
+func (r Ring) Contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		if (r[i][1] > p[1]) != (r[j][1] > p[1]) &&
+			p[0] < (r[j][0]-r[i][0])*(p[1]-r[i][1])/(r[j][1]-r[i][1])+r[i][0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// orient returns counterclockwise ring for outer rings and clockwise for inner ones
+// as required by RFC 7946
+func orient(r Ring, outer bool) Ring {
+	if (r.Area() > 0) == outer {
+		return r
+	}
+	reversed := make(Ring, len(r))
+	for i := range r {
+		reversed[len(r)-1-i] = r[i]
+	}
+	return reversed
+}
+
+// GeoJSONWriter collects elements and writes them as GeoJSON feature
+// collection on close, since geometries can't be assembled before
+// all nodes and ways are known.
+type GeoJSONWriter struct {
+	OSM
+	w io.Writer
+}
+
+// NewGeoJSONWriter returns new GeoJSONWriter
+func NewGeoJSONWriter(w io.Writer) *GeoJSONWriter {
+	return &GeoJSONWriter{w: w}
+}
+
+// Close writes the collected elements
+func (g *GeoJSONWriter) Close() error {
+	return json.NewEncoder(g.w).Encode(g.OSM.GeoJSON())
+}
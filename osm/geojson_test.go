@@ -0,0 +1,110 @@
+package osm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func geoJSONTestOSM() *OSM {
+	o := New()
+	coords := map[int64][2]float64{
+		1: {0, 0}, 2: {0, 10}, 3: {10, 10}, 4: {10, 0},
+		5: {2, 2}, 6: {2, 4}, 7: {4, 4}, 8: {4, 2},
+		9: {20, 20},
+	}
+	for id := int64(1); id <= 9; id++ {
+		lat, lon := coords[id][1], coords[id][0]
+		o.Nodes = append(o.Nodes, &Node{ID: id, Lat: &lat, Lon: &lon, Visible: true})
+	}
+	o.Nodes[8].Tags = Tags{{K: "amenity", V: "bench"}}
+
+	o.Ways = Ways{
+		// two halves of the outer ring, the second one is reversed
+		{ID: 10, Visible: true, Nodes: wayNodes{{ID: 1}, {ID: 2}, {ID: 3}}},
+		{ID: 11, Visible: true, Nodes: wayNodes{{ID: 1}, {ID: 4}, {ID: 3}}},
+		{ID: 12, Visible: true, Nodes: wayNodes{{ID: 5}, {ID: 6}, {ID: 7}, {ID: 8}, {ID: 5}}},
+		{ID: 13, Visible: true, Nodes: wayNodes{{ID: 5}, {ID: 6}, {ID: 7}, {ID: 8}, {ID: 5}},
+			Tags: Tags{{K: "building", V: "yes"}}},
+		{ID: 14, Visible: true, Nodes: wayNodes{{ID: 1}, {ID: 9}},
+			Tags: Tags{{K: "highway", V: "path"}}},
+	}
+	o.Relations = Relations{
+		{ID: 20, Visible: true, Version: 2, Tags: Tags{{K: "type", V: "multipolygon"}, {K: "landuse", V: "forest"}},
+			Members: Members{
+				{Type: "way", Ref: 10, Role: "outer"},
+				{Type: "way", Ref: 11, Role: "outer"},
+				{Type: "way", Ref: 12, Role: "inner"},
+			}},
+	}
+	return o
+}
+
+func TestGeoJSON(t *testing.T) {
+	fc := geoJSONTestOSM().GeoJSON()
+
+	geometries := map[string]*Geometry{}
+	for _, f := range fc.Features {
+		geometries[f.ID] = f.Geometry
+	}
+
+	expected := map[string]string{
+		"node/9":      "Point",
+		"way/13":      "Polygon",
+		"way/14":      "LineString",
+		"relation/20": "MultiPolygon",
+	}
+	if len(geometries) != len(expected) {
+		t.Errorf("incorrect features: %v", geometries)
+	}
+	for id, typ := range expected {
+		if g, ok := geometries[id]; !ok || g.Type != typ {
+			t.Errorf("incorrect geometry of %v: %v", id, g)
+		}
+	}
+
+	polygons := geometries["relation/20"].Coordinates.([]Polygon)
+	if len(polygons) != 1 || len(polygons[0]) != 2 {
+		t.Fatalf("incorrect multipolygon: %v", polygons)
+	}
+	if polygons[0][0].Area() <= 0 {
+		t.Errorf("outer ring must be counterclockwise")
+	}
+	if polygons[0][1].Area() >= 0 {
+		t.Errorf("inner ring must be clockwise")
+	}
+	if v := polygons[0][0].Area(); v != 100 {
+		t.Errorf("incorrect outer ring area: %v", v)
+	}
+}
+
+func TestJoinRings(t *testing.T) {
+	cases := []struct {
+		name     string
+		lines    [][]Point
+		expected []Ring
+	}{
+		{
+			name:     "closed line",
+			lines:    [][]Point{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+			expected: []Ring{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		},
+		{
+			name:     "reversed line",
+			lines:    [][]Point{{{0, 0}, {1, 0}}, {{0, 0}, {1, 1}, {1, 0}}},
+			expected: []Ring{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		},
+		{
+			name:     "open ring is skipped",
+			lines:    [][]Point{{{0, 0}, {1, 0}}, {{1, 0}, {1, 1}}},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := joinRings(tc.lines); !reflect.DeepEqual(v, tc.expected) {
+				t.Errorf("incorrect rings: %v", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package osm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Graph is a directed graph of relation memberships,
+// edges go from relations to their members
+type Graph struct {
+	Vertices []*GraphVertex `json:"vertices"`
+	Edges    []*GraphEdge   `json:"edges"`
+}
+
+// GraphVertex is an element of the membership graph
+type GraphVertex struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// GraphEdge is a membership of the element in the relation
+type GraphEdge struct {
+	Relation   int64  `json:"relation"`
+	MemberType string `json:"member_type"`
+	Member     int64  `json:"member"`
+	Role       string `json:"role"`
+}
+
+// NewGraph returns empty graph
+func NewGraph() *Graph {
+	return &Graph{Vertices: []*GraphVertex{}, Edges: []*GraphEdge{}}
+}
+
+// dotID returns the id of the element in dot output, like r123 for relation 123
+func dotID(elementType string, id int64) string {
+	return fmt.Sprintf("\"%c%d\"", elementType[0], id)
+}
+
+// dotQuote returns quoted dot string
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteDOT writes the graph in graphviz dot format
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("digraph memberships {\n")
+	for _, v := range g.Vertices {
+		label := fmt.Sprintf("%v %v", v.Type, v.ID)
+		if len(v.Name) != 0 {
+			label += "\n" + v.Name
+		}
+		shape := "box"
+		if v.Type == "relation" {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(b, "  %v [label=%v, shape=%v];\n", dotID(v.Type, v.ID), dotQuote(label), shape)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %v -> %v", dotID("relation", e.Relation), dotID(e.MemberType, e.Member))
+		if len(e.Role) != 0 {
+			fmt.Fprintf(b, " [label=%v]", dotQuote(e.Role))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.Flush()
+}
@@ -0,0 +1,33 @@
+package osm
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGraphWriteDOT(t *testing.T) {
+	g := NewGraph()
+	g.Vertices = []*GraphVertex{
+		{Type: "relation", ID: 1, Name: `Line "1"`},
+		{Type: "way", ID: 2},
+	}
+	g.Edges = []*GraphEdge{
+		{Relation: 1, MemberType: "way", Member: 2, Role: "forward"},
+		{Relation: 1, MemberType: "relation", Member: 1},
+	}
+
+	var b bytes.Buffer
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	expected := `digraph memberships {
+  "r1" [label="relation 1\nLine \"1\"", shape=ellipse];
+  "w2" [label="way 2", shape=box];
+  "r1" -> "w2" [label="forward"];
+  "r1" -> "r1";
+}
+`
+	if b.String() != expected {
+		t.Errorf("incorrect dot:\n%v\nexpected:\n%v", b.String(), expected)
+	}
+}
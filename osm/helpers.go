@@ -3,6 +3,7 @@ package osm
 import (
 	"encoding/json"
 	"encoding/xml"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -37,6 +38,11 @@ func (t *Time) Scan(v interface{}) error {
 	return t.processTime(v.(string))
 }
 
+// MarshalJSON - implement Marshaler interface
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
 // UnmarshalJSON - implement Unmarshaler interface
 func (t *Time) UnmarshalJSON(b []byte) error {
 	tr := strings.Trim(string(b), "\"")
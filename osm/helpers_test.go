@@ -1,6 +1,7 @@
 package osm
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -37,3 +38,28 @@ func TestTimeString(t *testing.T) {
 		})
 	}
 }
+
+func TestElementJSON(t *testing.T) {
+	w := &Way{
+		ID:        1,
+		Visible:   true,
+		Timestamp: Time(time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Nodes:     []wayNode{{ID: 2}, {ID: 3}},
+	}
+	b, err := json.Marshal(w)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	expected := `{"type":"way","id":1,"visible":true,"timestamp":"2012-01-01T00:00:00Z","nodes":[2,3]}`
+	if string(b) != expected {
+		t.Errorf("incorrect json %s, expected %s", b, expected)
+	}
+
+	decoded := &Way{}
+	if err := json.Unmarshal(b, decoded); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if decoded.ID != w.ID || !time.Time(decoded.Timestamp).Equal(time.Time(w.Timestamp)) || len(decoded.Nodes) != 2 || decoded.Nodes[1].ID != 3 {
+		t.Errorf("incorrect decoded way: %+v", decoded)
+	}
+}
@@ -0,0 +1,446 @@
+package osm
+
+import (
+	"math"
+	"sort"
+)
+
+// https://github.com/mapbox/vector-tile-spec/tree/master/2.1
+const (
+	mvtVersion = 2
+	mvtExtent  = 4096
+	// mvtBuffer is the number of tile units drawn outside of the tile,
+	// so lines and polygons don't have visible edges on tile borders.
+	mvtBuffer = 64
+	// mvtTolerance is the simplification tolerance in tile units
+	mvtTolerance = 1.0
+
+	mvtPoint      = 1
+	mvtLineString = 2
+	mvtPolygon    = 3
+
+	mvtMoveTo    = 1
+	mvtLineTo    = 2
+	mvtClosePath = 7
+)
+
+var mvtLayers = []string{"points", "lines", "polygons"}
+
+// TileBounds returns bounds of the web mercator tile in degrees
+func TileBounds(z, x, y uint32) (minLon, minLat, maxLon, maxLat float64) {
+	n := math.Exp2(float64(z))
+	minLon = float64(x)/n*360 - 180
+	maxLon = float64(x+1)/n*360 - 180
+	maxLat = tileLat(float64(y), n)
+	minLat = tileLat(float64(y+1), n)
+	return
+}
+
+func tileLat(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+// tileProjection converts lon/lat to tile units of the tile
+type tileProjection struct {
+	n    float64
+	x, y float64
+}
+
+func newTileProjection(z, x, y uint32) tileProjection {
+	return tileProjection{n: math.Exp2(float64(z)), x: float64(x), y: float64(y)}
+}
+
+func (t tileProjection) project(p Point) Point {
+	lat := math.Max(math.Min(p[1], 85.0511287798), -85.0511287798) * math.Pi / 180
+	x := (p[0] + 180) / 360 * t.n
+	y := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * t.n
+	return Point{(x - t.x) * mvtExtent, (y - t.y) * mvtExtent}
+}
+
+func (t tileProjection) line(line []Point) []Point {
+	projected := make([]Point, len(line))
+	for i := range line {
+		projected[i] = t.project(line[i])
+	}
+	return projected
+}
+
+// mvtFeature is a feature prepared for encoding
+type mvtFeature struct {
+	id       uint64
+	typ      int
+	tags     map[string]string
+	geometry [][]Point
+}
+
+// MarshalMVT encodes osm elements as Mapbox vector tile with points,
+// lines and polygons layers. Geometries are assembled the same way as
+// for GeoJSON, clipped to the tile and simplified for the zoom level.
+func (o *OSM) MarshalMVT(z, x, y uint32) []byte {
+	proj := newTileProjection(z, x, y)
+	layers := make([][]*mvtFeature, len(mvtLayers))
+
+	for _, f := range o.GeoJSON().Features {
+		mf := &mvtFeature{id: mvtFeatureID(f.Meta), tags: f.Properties}
+		switch f.Geometry.Type {
+		case "Point":
+			p := proj.project(f.Geometry.Coordinates.(Point))
+			if !insideTile(p) {
+				continue
+			}
+			mf.typ = mvtPoint
+			mf.geometry = [][]Point{{p}}
+		case "LineString":
+			line := proj.line(f.Geometry.Coordinates.([]Point))
+			for _, part := range clipLine(line) {
+				part = simplify(part, mvtTolerance)
+				if len(part) >= 2 {
+					mf.geometry = append(mf.geometry, part)
+				}
+			}
+			mf.typ = mvtLineString
+		case "Polygon":
+			mf.geometry = clipPolygon(f.Geometry.Coordinates.(Polygon), proj)
+			mf.typ = mvtPolygon
+		case "MultiPolygon":
+			for _, polygon := range f.Geometry.Coordinates.([]Polygon) {
+				mf.geometry = append(mf.geometry, clipPolygon(polygon, proj)...)
+			}
+			mf.typ = mvtPolygon
+		}
+		if len(mf.geometry) == 0 {
+			continue
+		}
+		layers[mf.typ-1] = append(layers[mf.typ-1], mf)
+	}
+
+	var tile protoBuffer
+	for i, features := range layers {
+		if len(features) == 0 {
+			continue
+		}
+		tile.Bytes(3, encodeMVTLayer(mvtLayers[i], features))
+	}
+	return tile
+}
+
+// mvtFeatureID makes unique feature id of osm type and id
+func mvtFeatureID(m *FeatureMeta) uint64 {
+	types := map[string]uint64{"node": 1, "way": 2, "relation": 3}
+	return uint64(m.ID)*10 + types[m.Type]
+}
+
+func insideTile(p Point) bool {
+	return p[0] >= 0 && p[0] < mvtExtent && p[1] >= 0 && p[1] < mvtExtent
+}
+
+// clipLine returns parts of the line inside of the buffered tile
+func clipLine(line []Point) [][]Point {
+	const min, max = -mvtBuffer, mvtExtent + mvtBuffer
+
+	var parts [][]Point
+	var part []Point
+	for i := 0; i+1 < len(line); i++ {
+		a, b, ok := clipSegment(line[i], line[i+1], min, max)
+		if !ok {
+			if len(part) != 0 {
+				parts = append(parts, part)
+				part = nil
+			}
+			continue
+		}
+		if len(part) == 0 {
+			part = append(part, a)
+		}
+		part = append(part, b)
+		if b != line[i+1] {
+			// the segment leaves the tile
+			parts = append(parts, part)
+			part = nil
+		}
+	}
+	if len(part) != 0 {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// clipSegment clips the segment by Liang-Barsky algorithm
+func clipSegment(a, b Point, min, max float64) (Point, Point, bool) {
+	t0, t1 := 0.0, 1.0
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	for _, edge := range [][2]float64{
+		{-dx, a[0] - min},
+		{dx, max - a[0]},
+		{-dy, a[1] - min},
+		{dy, max - a[1]},
+	} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return a, b, false
+			}
+			continue
+		}
+		r := q / p
+		if p < 0 {
+			if r > t1 {
+				return a, b, false
+			}
+			if r > t0 {
+				t0 = r
+			}
+		} else {
+			if r < t0 {
+				return a, b, false
+			}
+			if r < t1 {
+				t1 = r
+			}
+		}
+	}
+	clippedA, clippedB := a, b
+	if t0 > 0 {
+		clippedA = Point{a[0] + t0*dx, a[1] + t0*dy}
+	}
+	if t1 < 1 {
+		clippedB = Point{a[0] + t1*dx, a[1] + t1*dy}
+	}
+	return clippedA, clippedB, true
+}
+
+// clipPolygon projects, clips and simplifies rings of the polygon.
+// Outer ring gets positive area in tile units and inner rings negative as
+// required by the spec. Nothing is returned if the outer ring is out of the tile.
+func clipPolygon(polygon Polygon, proj tileProjection) [][]Point {
+	var rings [][]Point
+	for i, ring := range polygon {
+		clipped := clipRing(Ring(proj.line(ring)))
+		clipped = Ring(simplify(clipped, mvtTolerance))
+		if len(clipped) < 4 || clipped.Area() == 0 {
+			if i == 0 {
+				return nil
+			}
+			continue
+		}
+		rings = append(rings, orient(clipped, i == 0))
+	}
+	return rings
+}
+
+// clipRing clips the ring by Sutherland-Hodgman algorithm
+func clipRing(ring Ring) Ring {
+	const min, max = -mvtBuffer, mvtExtent + mvtBuffer
+
+	edges := []struct {
+		inside    func(p Point) bool
+		intersect func(a, b Point) Point
+	}{
+		{
+			inside:    func(p Point) bool { return p[0] >= min },
+			intersect: func(a, b Point) Point { return intersectX(a, b, min) },
+		},
+		{
+			inside:    func(p Point) bool { return p[0] <= max },
+			intersect: func(a, b Point) Point { return intersectX(a, b, max) },
+		},
+		{
+			inside:    func(p Point) bool { return p[1] >= min },
+			intersect: func(a, b Point) Point { return intersectY(a, b, min) },
+		},
+		{
+			inside:    func(p Point) bool { return p[1] <= max },
+			intersect: func(a, b Point) Point { return intersectY(a, b, max) },
+		},
+	}
+
+	result := ring
+	for _, edge := range edges {
+		if len(result) == 0 {
+			return nil
+		}
+		input := result
+		result = nil
+		for i := 0; i+1 < len(input); i++ {
+			a, b := input[i], input[i+1]
+			switch {
+			case edge.inside(a) && edge.inside(b):
+				result = append(result, b)
+			case edge.inside(a):
+				result = append(result, edge.intersect(a, b))
+			case edge.inside(b):
+				result = append(result, edge.intersect(a, b), b)
+			}
+		}
+		if len(result) != 0 {
+			result = append(Ring{result[len(result)-1]}, result...)
+		}
+	}
+	return result
+}
+
+func intersectX(a, b Point, x float64) Point {
+	return Point{x, a[1] + (b[1]-a[1])*(x-a[0])/(b[0]-a[0])}
+}
+
+func intersectY(a, b Point, y float64) Point {
+	return Point{a[0] + (b[0]-a[0])*(y-a[1])/(b[1]-a[1]), y}
+}
+
+// simplify simplifies the line by Douglas-Peucker algorithm
+func simplify(line []Point, tolerance float64) []Point {
+	if len(line) < 3 {
+		return line
+	}
+
+	keep := make([]bool, len(line))
+	keep[0], keep[len(line)-1] = true, true
+	stack := [][2]int{{0, len(line) - 1}}
+	for len(stack) != 0 {
+		first, last := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+
+		index, maxDist := -1, tolerance
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(line[i], line[first], line[last]); d > maxDist {
+				index, maxDist = i, d
+			}
+		}
+		if index != -1 {
+			keep[index] = true
+			stack = append(stack, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+
+	simplified := make([]Point, 0, len(line))
+	for i := range line {
+		if keep[i] {
+			simplified = append(simplified, line[i])
+		}
+	}
+	return simplified
+}
+
+// segmentDistance returns distance from p to the segment a-b
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	if dx == 0 && dy == 0 {
+		return math.Hypot(p[0]-a[0], p[1]-a[1])
+	}
+	t := ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / (dx*dx + dy*dy)
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p[0]-(a[0]+t*dx), p[1]-(a[1]+t*dy))
+}
+
+func encodeMVTLayer(name string, features []*mvtFeature) []byte {
+	keys := newStringTable()
+	values := newStringTable()
+
+	var encoded []protoBuffer
+	for _, f := range features {
+		geometry := encodeMVTGeometry(f.typ, f.geometry)
+		if len(geometry) == 0 {
+			continue
+		}
+
+		tagKeys := make([]string, 0, len(f.tags))
+		for k := range f.tags {
+			tagKeys = append(tagKeys, k)
+		}
+		sort.Strings(tagKeys)
+		tags := make([]int64, 0, 2*len(tagKeys))
+		for _, k := range tagKeys {
+			if len(k) == 0 || len(f.tags[k]) == 0 {
+				continue
+			}
+			// string tables start with the empty string, it isn't written to the layer
+			tags = append(tags, keys.id(k)-1, values.id(f.tags[k])-1)
+		}
+
+		var feature protoBuffer
+		feature.Uint(1, f.id)
+		feature.PackedInts(2, tags)
+		feature.Int(3, int64(f.typ))
+		feature.PackedInts(4, geometry)
+		encoded = append(encoded, feature)
+	}
+
+	var layer protoBuffer
+	layer.Uint(15, mvtVersion)
+	layer.String(1, name)
+	for _, f := range encoded {
+		layer.Bytes(2, f)
+	}
+	for _, k := range keys.strings[1:] {
+		layer.String(3, k)
+	}
+	for _, v := range values.strings[1:] {
+		var value protoBuffer
+		value.String(1, v)
+		layer.Bytes(4, value)
+	}
+	layer.Uint(5, mvtExtent)
+	return layer
+}
+
+// encodeMVTGeometry encodes geometry as a sequence of drawing commands
+func encodeMVTGeometry(typ int, geometry [][]Point) []int64 {
+	var commands []int64
+	var cx, cy int64
+	command := func(id, count int) int64 {
+		return int64(id&7) | int64(count)<<3
+	}
+	param := func(p Point) (int64, int64) {
+		x, y := int64(math.Round(p[0])), int64(math.Round(p[1]))
+		dx, dy := int64(zigzag(x-cx)), int64(zigzag(y-cy))
+		cx, cy = x, y
+		return dx, dy
+	}
+
+	if typ == mvtPoint {
+		var points []int64
+		for _, part := range geometry {
+			for _, p := range part {
+				dx, dy := param(p)
+				points = append(points, dx, dy)
+			}
+		}
+		return append([]int64{command(mvtMoveTo, len(points)/2)}, points...)
+	}
+
+	for _, part := range geometry {
+		if typ == mvtPolygon {
+			// the closing point is replaced by ClosePath command
+			part = part[:len(part)-1]
+		}
+		part = dedupe(part)
+		if len(part) < 2 || (typ == mvtPolygon && len(part) < 3) {
+			continue
+		}
+
+		dx, dy := param(part[0])
+		commands = append(commands, command(mvtMoveTo, 1), dx, dy)
+		commands = append(commands, command(mvtLineTo, len(part)-1))
+		for _, p := range part[1:] {
+			dx, dy := param(p)
+			commands = append(commands, dx, dy)
+		}
+		if typ == mvtPolygon {
+			commands = append(commands, command(mvtClosePath, 1))
+		}
+	}
+	return commands
+}
+
+// dedupe removes consecutive points which are the same after rounding to tile units
+func dedupe(line []Point) []Point {
+	result := make([]Point, 0, len(line))
+	for _, p := range line {
+		p = Point{math.Round(p[0]), math.Round(p[1])}
+		if len(result) != 0 && result[len(result)-1] == p {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package osm
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTileBounds(t *testing.T) {
+	minLon, minLat, maxLon, maxLat := TileBounds(1, 1, 0)
+	if minLon != 0 || maxLon != 180 || minLat != 0 || math.Abs(maxLat-85.0511287798) > 1e-9 {
+		t.Errorf("incorrect bounds: %v %v %v %v", minLon, minLat, maxLon, maxLat)
+	}
+}
+
+func TestClipLine(t *testing.T) {
+	cases := []struct {
+		name     string
+		line     []Point
+		expected [][]Point
+	}{
+		{
+			name:     "inside",
+			line:     []Point{{10, 10}, {20, 20}},
+			expected: [][]Point{{{10, 10}, {20, 20}}},
+		},
+		{
+			name:     "outside",
+			line:     []Point{{-1000, 10}, {-1000, 20}},
+			expected: nil,
+		},
+		{
+			name: "crosses the tile twice",
+			line: []Point{{10, 10}, {10, -1000}, {20, -1000}, {20, 10}},
+			expected: [][]Point{
+				{{10, 10}, {10, -mvtBuffer}},
+				{{20, -mvtBuffer}, {20, 10}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := clipLine(tc.line); !reflect.DeepEqual(v, tc.expected) {
+				t.Errorf("incorrect parts: %v", v)
+			}
+		})
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	line := []Point{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 5}, {4, 6}, {5, 7}}
+	expected := []Point{{0, 0}, {2, -0.1}, {3, 5}, {5, 7}}
+	if v := simplify(line, 1); !reflect.DeepEqual(v, expected) {
+		t.Errorf("incorrect line: %v", v)
+	}
+}
+
+func TestMarshalMVT(t *testing.T) {
+	tile := geoJSONTestOSM().MarshalMVT(0, 0, 0)
+
+	var layers []string
+	r := newProtoReader(tile)
+	for r.Next() {
+		if r.field != 3 {
+			t.Fatalf("unexpected field: %v", r.field)
+		}
+		layer := newProtoReader(r.Bytes())
+		features := 0
+		var name string
+		for layer.Next() {
+			switch layer.field {
+			case 1:
+				name = layer.Text()
+			case 2:
+				features++
+				layer.Skip()
+			default:
+				layer.Skip()
+			}
+		}
+		if err := layer.Err(); err != nil {
+			t.Fatalf("layer error: %v", err)
+		}
+		if features == 0 {
+			t.Errorf("empty layer %v", name)
+		}
+		layers = append(layers, name)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("tile error: %v", err)
+	}
+
+	if !reflect.DeepEqual(layers, mvtLayers) {
+		t.Errorf("incorrect layers: %v", layers)
+	}
+}
@@ -13,6 +13,11 @@ func (x xmlNameJSONTypeNode) MarshalJSON() ([]byte, error) {
 	return []byte(`"node"`), nil
 }
 
+// UnmarshalJSON ignores the type, it's known from the struct type
+func (x *xmlNameJSONTypeNode) UnmarshalJSON(b []byte) error {
+	return nil
+}
+
 // Node is an osm point and allows for marshalling to/from osm xml.
 type Node struct {
 	XMLName     xmlNameJSONTypeNode `xml:"node" json:"type"`
@@ -0,0 +1,525 @@
+package osm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// OPLWriter writes elements in OPL format, one element per line.
+// https://osmcode.org/opl-file-format/
+type OPLWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewOPLWriter returns new OPLWriter
+func NewOPLWriter(w io.Writer) *OPLWriter {
+	return &OPLWriter{w: w}
+}
+
+// WriteNode writes node
+func (o *OPLWriter) WriteNode(n *Node) error {
+	b := o.begin('n', n.ID, n.Version, n.Visible, n.ChangesetID, n.Timestamp, n.User, n.UserID, n.Tags)
+	b = append(b, " x"...)
+	b = appendOPLCoordinate(b, n.Lon)
+	b = append(b, " y"...)
+	b = appendOPLCoordinate(b, n.Lat)
+	return o.end(b)
+}
+
+// WriteWay writes way
+func (o *OPLWriter) WriteWay(w *Way) error {
+	b := o.begin('w', w.ID, w.Version, w.Visible, w.ChangesetID, w.Timestamp, w.User, w.UserID, w.Tags)
+	b = append(b, " N"...)
+	for i, n := range w.Nodes {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, 'n')
+		b = strconv.AppendInt(b, n.ID, 10)
+	}
+	return o.end(b)
+}
+
+// WriteRelation writes relation
+func (o *OPLWriter) WriteRelation(r *Relation) error {
+	b := o.begin('r', r.ID, r.Version, r.Visible, r.ChangesetID, r.Timestamp, r.User, r.UserID, r.Tags)
+	b = append(b, " M"...)
+	for i, m := range r.Members {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if len(m.Type) != 0 {
+			b = append(b, m.Type[0])
+		}
+		b = strconv.AppendInt(b, m.Ref, 10)
+		b = append(b, '@')
+		b = appendOPLString(b, m.Role)
+	}
+	return o.end(b)
+}
+
+// WriteChangeset writes changeset
+func (o *OPLWriter) WriteChangeset(c *Changeset) error {
+	b := append(o.buf[:0], 'c')
+	b = strconv.AppendInt(b, c.ID, 10)
+	b = append(b, " k"...)
+	b = strconv.AppendInt(b, int64(c.ChangesCount), 10)
+	b = append(b, " s"...)
+	b = append(b, c.CreatedAt.String()...)
+	b = append(b, " e"...)
+	if !c.Open {
+		b = append(b, c.ClosedAt.String()...)
+	}
+	b = append(b, " d"...)
+	b = strconv.AppendInt(b, int64(c.CommentsCount), 10)
+	b = appendOPLUser(b, c.User, c.UserID)
+	b = append(b, " x"...)
+	b = appendOPLCoordinate(b, &c.MinLon)
+	b = append(b, " y"...)
+	b = appendOPLCoordinate(b, &c.MinLat)
+	b = append(b, " X"...)
+	b = appendOPLCoordinate(b, &c.MaxLon)
+	b = append(b, " Y"...)
+	b = appendOPLCoordinate(b, &c.MaxLat)
+	b = appendOPLTags(b, c.Tags)
+	return o.end(b)
+}
+
+// Close does nothing, every line is written at once
+func (o *OPLWriter) Close() error {
+	return nil
+}
+
+func (o *OPLWriter) begin(typ byte, id int64, version int, visible bool, changeset int64,
+	timestamp Time, user *string, userID *int64, tags Tags) []byte {
+	b := append(o.buf[:0], typ)
+	b = strconv.AppendInt(b, id, 10)
+	b = append(b, " v"...)
+	b = strconv.AppendInt(b, int64(version), 10)
+	if visible {
+		b = append(b, " dV"...)
+	} else {
+		b = append(b, " dD"...)
+	}
+	b = append(b, " c"...)
+	b = strconv.AppendInt(b, changeset, 10)
+	b = append(b, " t"...)
+	b = append(b, timestamp.String()...)
+	b = appendOPLUser(b, user, userID)
+	return appendOPLTags(b, tags)
+}
+
+func (o *OPLWriter) end(b []byte) error {
+	b = append(b, '\n')
+	o.buf = b
+	_, err := o.w.Write(b)
+	return err
+}
+
+func appendOPLUser(b []byte, user *string, userID *int64) []byte {
+	if user == nil || userID == nil {
+		return append(b, " i0 u"...)
+	}
+	b = append(b, " i"...)
+	b = strconv.AppendInt(b, *userID, 10)
+	b = append(b, " u"...)
+	return appendOPLString(b, *user)
+}
+
+func appendOPLTags(b []byte, tags Tags) []byte {
+	b = append(b, " T"...)
+	for i, t := range tags {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendOPLString(b, t.K)
+		b = append(b, '=')
+		b = appendOPLString(b, t.V)
+	}
+	return b
+}
+
+// appendOPLCoordinate writes the coordinate with 7 decimal places at most
+// and without trailing zeros like osmium does
+func appendOPLCoordinate(b []byte, c *float64) []byte {
+	if c == nil {
+		return b
+	}
+	b = strconv.AppendFloat(b, *c, 'f', 7, 64)
+	for b[len(b)-1] == '0' {
+		b = b[:len(b)-1]
+	}
+	if b[len(b)-1] == '.' {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// isOPLPlain reports whether osmium writes the character as it is,
+// printable characters up to U+05FF are plain except space, soft hyphen
+// and the separators ",=@%" of OPL
+func isOPLPlain(r rune) bool {
+	switch {
+	case r <= ' ' || r == 0x7f || (r >= 0x80 && r <= 0xa0) || r == 0xad || r > 0x5ff:
+		return false
+	case r == ',' || r == '=' || r == '@' || r == '%':
+		return false
+	}
+	return true
+}
+
+// appendOPLString escapes characters which aren't plain as %hex%,
+// the code point is written with an even number of lowercase digits like osmium does
+func appendOPLString(b []byte, s string) []byte {
+	const digits = "0123456789abcdef"
+	for _, r := range s {
+		if isOPLPlain(r) {
+			b = append(b, string(r)...)
+			continue
+		}
+		b = append(b, '%')
+		if r > 0xffff {
+			b = append(b, digits[r>>20&0xf], digits[r>>16&0xf])
+		}
+		if r > 0xff {
+			b = append(b, digits[r>>12&0xf], digits[r>>8&0xf])
+		}
+		b = append(b, digits[r>>4&0xf], digits[r&0xf], '%')
+	}
+	return b
+}
+
+// MarshalOPL encodes osm elements in OPL format
+func (o *OSM) MarshalOPL() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := NewOPLWriter(buf)
+	if err := o.Stream(w); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), w.Close()
+}
+
+// UnmarshalOPL decodes osm elements from OPL format
+func UnmarshalOPL(data []byte) (*OSM, error) {
+	o := New()
+	if err := Copy(o, NewOPLScanner(bytes.NewReader(data))); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// OPLScanner reads elements in OPL format line by line
+type OPLScanner struct {
+	s      *bufio.Scanner
+	c      io.Closer
+	line   int
+	object Object
+	err    error
+}
+
+// NewOPLScanner returns new OPLScanner
+func NewOPLScanner(r io.Reader) *OPLScanner {
+	s := &OPLScanner{s: bufio.NewScanner(r)}
+	// relations may have tens of thousands of members
+	s.s.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	if c, ok := r.(io.Closer); ok {
+		s.c = c
+	}
+	return s
+}
+
+// Scan reads the next element, false is returned at the end of the input or on error
+func (s *OPLScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	s.object = nil
+
+	for s.s.Scan() {
+		s.line++
+		line := strings.TrimSpace(s.s.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		o, err := parseOPLLine(line)
+		if err != nil {
+			s.err = fmt.Errorf("osm: opl line %v: %v", s.line, err)
+			return false
+		}
+		s.object = o
+		return true
+	}
+	s.err = s.s.Err()
+	return false
+}
+
+// Object returns the last read element
+func (s *OPLScanner) Object() Object {
+	return s.object
+}
+
+// Err returns the first error, the end of the input isn't an error
+func (s *OPLScanner) Err() error {
+	return s.err
+}
+
+// Close closes the underlying reader if it's closer
+func (s *OPLScanner) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+// oplElement contains fields shared by all element types
+type oplElement struct {
+	id        int64
+	version   int
+	visible   bool
+	changeset int64
+	timestamp Time
+	user      *string
+	userID    *int64
+	tags      Tags
+}
+
+func parseOPLLine(line string) (Object, error) {
+	fields := strings.Split(line, " ")
+	typ := fields[0][0]
+	id, err := strconv.ParseInt(fields[0][1:], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	e := oplElement{id: id, visible: true}
+	var uid int64
+	var user string
+	hasUser := false
+	values := map[byte]string{}
+	for _, f := range fields[1:] {
+		if len(f) == 0 {
+			continue
+		}
+		key, value := f[0], f[1:]
+		switch key {
+		case 'v':
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, err
+			}
+			e.version = v
+		case 'd':
+			if typ == 'c' {
+				values[key] = value
+				continue
+			}
+			if value != "V" && value != "D" {
+				return nil, fmt.Errorf("invalid visibility %q", value)
+			}
+			e.visible = value == "V"
+		case 'c':
+			if e.changeset, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, err
+			}
+		case 't':
+			if len(value) != 0 {
+				if err := e.timestamp.processTime(value); err != nil {
+					return nil, err
+				}
+			}
+		case 'i':
+			if uid, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, err
+			}
+			hasUser = true
+		case 'u':
+			if user, err = unescapeOPL(value); err != nil {
+				return nil, err
+			}
+			hasUser = true
+		case 'T':
+			if e.tags, err = parseOPLTags(value); err != nil {
+				return nil, err
+			}
+		default:
+			values[key] = value
+		}
+	}
+	if hasUser && (uid != 0 || len(user) != 0) {
+		e.user, e.userID = &user, &uid
+	}
+
+	switch typ {
+	case 'n':
+		n := &Node{ID: e.id, Version: e.version, Visible: e.visible, ChangesetID: e.changeset,
+			Timestamp: e.timestamp, User: e.user, UserID: e.userID, Tags: e.tags}
+		if n.Lon, err = parseOPLCoordinate(values['x']); err != nil {
+			return nil, err
+		}
+		if n.Lat, err = parseOPLCoordinate(values['y']); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case 'w':
+		w := &Way{ID: e.id, Version: e.version, Visible: e.visible, ChangesetID: e.changeset,
+			Timestamp: e.timestamp, User: e.user, UserID: e.userID, Tags: e.tags}
+		if len(values['N']) != 0 {
+			for _, ref := range strings.Split(values['N'], ",") {
+				if len(ref) < 2 || ref[0] != 'n' {
+					return nil, fmt.Errorf("invalid way node %q", ref)
+				}
+				id, err := strconv.ParseInt(ref[1:], 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				w.Nodes = append(w.Nodes, wayNode{ID: id})
+			}
+		}
+		return w, nil
+	case 'r':
+		r := &Relation{ID: e.id, Version: e.version, Visible: e.visible, ChangesetID: e.changeset,
+			Timestamp: e.timestamp, User: e.user, UserID: e.userID, Tags: e.tags}
+		if len(values['M']) != 0 {
+			for _, m := range strings.Split(values['M'], ",") {
+				member, err := parseOPLMember(m)
+				if err != nil {
+					return nil, err
+				}
+				r.Members = append(r.Members, member)
+			}
+		}
+		return r, nil
+	case 'c':
+		return parseOPLChangeset(e, values)
+	}
+	return nil, fmt.Errorf("unknown element type %q", typ)
+}
+
+func parseOPLChangeset(e oplElement, values map[byte]string) (*Changeset, error) {
+	c := &Changeset{ID: e.id, User: e.user, UserID: e.userID, Tags: e.tags, Open: len(values['e']) == 0}
+	var err error
+	if c.ChangesCount, err = atoiOrZero(values['k']); err != nil {
+		return nil, err
+	}
+	if c.CommentsCount, err = atoiOrZero(values['d']); err != nil {
+		return nil, err
+	}
+	if len(values['s']) != 0 {
+		if err := c.CreatedAt.processTime(values['s']); err != nil {
+			return nil, err
+		}
+	}
+	if !c.Open {
+		if err := c.ClosedAt.processTime(values['e']); err != nil {
+			return nil, err
+		}
+	}
+	for key, v := range map[byte]*float64{'x': &c.MinLon, 'y': &c.MinLat, 'X': &c.MaxLon, 'Y': &c.MaxLat} {
+		coordinate, err := parseOPLCoordinate(values[key])
+		if err != nil {
+			return nil, err
+		}
+		if coordinate != nil {
+			*v = *coordinate
+		}
+	}
+	return c, nil
+}
+
+func atoiOrZero(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func parseOPLCoordinate(s string) (*float64, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func parseOPLTags(s string) (Tags, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var tags Tags
+	for _, kv := range strings.Split(s, ",") {
+		i := strings.IndexByte(kv, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("invalid tag %q", kv)
+		}
+		k, err := unescapeOPL(kv[:i])
+		if err != nil {
+			return nil, err
+		}
+		v, err := unescapeOPL(kv[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, &Tag{K: k, V: v})
+	}
+	return tags, nil
+}
+
+var oplMemberTypes = map[byte]string{'n': "node", 'w': "way", 'r': "relation"}
+
+func parseOPLMember(s string) (Member, error) {
+	i := strings.IndexByte(s, '@')
+	if len(s) < 2 || i == -1 {
+		return Member{}, fmt.Errorf("invalid member %q", s)
+	}
+	typ, ok := oplMemberTypes[s[0]]
+	if !ok {
+		return Member{}, fmt.Errorf("invalid member type %q", s[0])
+	}
+	ref, err := strconv.ParseInt(s[1:i], 10, 64)
+	if err != nil {
+		return Member{}, err
+	}
+	role, err := unescapeOPL(s[i+1:])
+	if err != nil {
+		return Member{}, err
+	}
+	return Member{Type: typ, Ref: ref, Role: role}, nil
+}
+
+var errOPLEscape = errors.New("invalid escape sequence")
+
+func unescapeOPL(s string) (string, error) {
+	if strings.IndexByte(s, '%') == -1 {
+		return s, nil
+	}
+	var b strings.Builder
+	for len(s) != 0 {
+		i := strings.IndexByte(s, '%')
+		if i == -1 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		j := strings.IndexByte(s, '%')
+		if j == -1 {
+			return "", errOPLEscape
+		}
+		r, err := strconv.ParseInt(s[:j], 16, 32)
+		if err != nil {
+			return "", errOPLEscape
+		}
+		b.WriteRune(rune(r))
+		s = s[j+1:]
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,114 @@
+package osm
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOPLWriter(t *testing.T) {
+	escaped := testOSM()
+	escaped.Nodes[0].Tags = Tags{
+		{K: "name", V: "a b,c=d@e%f"},
+		{K: "name:de", V: "Straße <Ü>"},
+		{K: "name:ru", V: "Улица"},
+		{K: "note", V: "tab\tsoft­hyphen nbsp"},
+		{K: "symbol", V: "€ 🚲"},
+	}
+	escaped.Nodes[1].User, escaped.Nodes[1].UserID = escaped.Nodes[0].User, escaped.Nodes[0].UserID
+	name := "map per"
+	escaped.Nodes[1].User = &name
+	escaped.Ways, escaped.Relations = nil, nil
+
+	deleted := testOSM()
+	deleted.Nodes[1].Visible = false
+	deleted.Nodes[1].Lat, deleted.Nodes[1].Lon = nil, nil
+	deleted.Ways[0].Visible, deleted.Ways[0].Nodes, deleted.Ways[0].Tags = false, nil, nil
+	deleted.Relations[0].Visible, deleted.Relations[0].Members = false, nil
+
+	members := New()
+	members.Relations = Relations{
+		{ID: 5, Visible: true, Version: 1, ChangesetID: 13, Timestamp: testOSM().Nodes[0].Timestamp,
+			Members: Members{
+				{Type: "node", Ref: 1, Role: "stop"},
+				{Type: "way", Ref: 3, Role: "platform,entry"},
+				{Type: "relation", Ref: 4, Role: ""},
+				{Type: "relation", Ref: 6, Role: "sub@area"},
+			},
+			Tags: Tags{{K: "type", V: "route"}}},
+	}
+
+	coordinates := New()
+	for i, c := range [][2]float64{{0, 0}, {-0.1, 51.5}, {179.9999999, -89.0000001}, {-180, 90}} {
+		lon, lat := c[0], c[1]
+		coordinates.Nodes = append(coordinates.Nodes, &Node{ID: int64(i + 1), Lon: &lon, Lat: &lat, Visible: true,
+			Version: 1, ChangesetID: 1, Timestamp: testOSM().Nodes[0].Timestamp})
+	}
+
+	cases := []struct {
+		name string
+		osm  *OSM
+	}{
+		{name: "elements", osm: testOSM()},
+		{name: "escaping", osm: escaped},
+		{name: "deleted", osm: deleted},
+		{name: "members", osm: members},
+		{name: "coordinates", osm: coordinates},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.osm.MarshalOPL()
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tc.name+".opl")
+			if *update {
+				if err := ioutil.WriteFile(golden, data, 0644); err != nil {
+					t.Fatalf("update error: %v", err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("golden file error: %v", err)
+			}
+			if !bytes.Equal(data, expected) {
+				t.Errorf("incorrect output:\n%s\nexpected:\n%s", data, expected)
+			}
+
+			decoded, err := UnmarshalOPL(data)
+			if err != nil {
+				t.Fatalf("unmarshal error: %v", err)
+			}
+			if !reflect.DeepEqual(decoded.Objects(), tc.osm.Objects()) {
+				t.Errorf("incorrect round trip:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestOPLScannerErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+	}{
+		{name: "unknown type", line: "x1 v1"},
+		{name: "invalid version", line: "n1 vx"},
+		{name: "invalid way node", line: "w1 Nw2"},
+		{name: "invalid member type", line: "r1 Mx1@"},
+		{name: "invalid escape", line: "n1 Tname=%zz%"},
+		{name: "unterminated escape", line: "n1 Tname=%20"},
+		{name: "invalid visibility", line: "n1 dX"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := UnmarshalOPL([]byte(tc.line)); err == nil {
+				t.Errorf("expected error for %q", tc.line)
+			}
+		})
+	}
+}
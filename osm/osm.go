@@ -31,12 +31,21 @@ type OSM struct {
 	Attribution string `xml:"attribution,attr,omitempty"`
 	License     string `xml:"license,attr,omitempty"`
 
+	Bounds     *Bounds    `xml:"bounds,omitempty"`
 	Nodes      Nodes      `xml:"node"`
 	Ways       Ways       `xml:"way"`
 	Relations  Relations  `xml:"relation"`
 	Changesets Changesets `xml:"changeset"`
 }
 
+// Bounds is the area of map responses
+type Bounds struct {
+	MinLat float64 `xml:"minlat,attr" json:"minlat"`
+	MinLon float64 `xml:"minlon,attr" json:"minlon"`
+	MaxLat float64 `xml:"maxlat,attr" json:"maxlat"`
+	MaxLon float64 `xml:"maxlon,attr" json:"maxlon"`
+}
+
 // New creates osm object
 func New() *OSM {
 	return &OSM{
@@ -82,9 +91,10 @@ func (o OSM) MarshalJSON() ([]byte, error) {
 		Copyright   string  `json:"copyright,omitempty"`
 		Attribution string  `json:"attribution,omitempty"`
 		License     string  `json:"license,omitempty"`
+		Bounds      *Bounds `json:"bounds,omitempty"`
 		Elements    Objects `json:"elements"`
 	}{o.Version, o.Generator, o.Copyright,
-		o.Attribution, o.License, o.Objects()}
+		o.Attribution, o.License, o.Bounds, o.Objects()}
 
 	return json.Marshal(s)
 }
@@ -92,7 +102,21 @@ func (o OSM) MarshalJSON() ([]byte, error) {
 // MarshalXML implements the xml.Marshaller method to allow for the
 // correct wrapper/start element case and attr data.
 func (o OSM) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	start.Name.Local = "osm"
+	start = o.startElement()
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if err := o.marshalInnerXML(e); err != nil {
+		return err
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// startElement returns the osm wrapper element with the header attributes.
+func (o *OSM) startElement() xml.StartElement {
+	start := xml.StartElement{Name: xml.Name{Local: "osm"}}
 	start.Attr = make([]xml.Attr, 0, 5)
 
 	if o.Version != 0 {
@@ -118,15 +142,7 @@ func (o OSM) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "license"}, Value: o.License})
 	}
 
-	if err := e.EncodeToken(start); err != nil {
-		return err
-	}
-
-	if err := o.marshalInnerXML(e); err != nil {
-		return err
-	}
-
-	return e.EncodeToken(start.End())
+	return start
 }
 
 func (o *OSM) marshalInnerXML(e *xml.Encoder) error {
@@ -134,6 +150,12 @@ func (o *OSM) marshalInnerXML(e *xml.Encoder) error {
 		return nil
 	}
 
+	if o.Bounds != nil {
+		if err := e.EncodeElement(o.Bounds, xml.StartElement{Name: xml.Name{Local: "bounds"}}); err != nil {
+			return err
+		}
+	}
+
 	if err := e.Encode(o.Nodes); err != nil {
 		return err
 	}
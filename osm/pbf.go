@@ -0,0 +1,385 @@
+package osm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// https://wiki.openstreetmap.org/wiki/PBF_Format
+const (
+	pbfBlockSize       = 8000
+	pbfGranularity     = 100
+	pbfDateGranularity = 1000
+
+	pbfHeaderType = "OSMHeader"
+	pbfDataType   = "OSMData"
+)
+
+const (
+	pbfNone = iota
+	pbfNodes
+	pbfWays
+	pbfRelations
+	pbfChangesets
+)
+
+var pbfMemberTypes = map[string]int64{
+	"node":     0,
+	"way":      1,
+	"relation": 2,
+}
+
+// PBFWriter writes osm pbf incrementally. Elements are collected into
+// primitive blocks of at most 8000 elements of the same type, every block
+// is compressed with zlib and written as soon as it is full.
+type PBFWriter struct {
+	w             io.Writer
+	historical    bool
+	headerWritten bool
+
+	kind       int
+	nodes      Nodes
+	ways       Ways
+	relations  Relations
+	changesets Changesets
+}
+
+// NewPBFWriter returns new PBFWriter. Historical writer requires HistoricalInformation
+// feature from readers, it's used for history with several versions of elements.
+func NewPBFWriter(w io.Writer, historical bool) *PBFWriter {
+	return &PBFWriter{w: w, historical: historical}
+}
+
+// WriteNode writes node
+func (p *PBFWriter) WriteNode(n *Node) error {
+	if err := p.switchBlock(pbfNodes); err != nil {
+		return err
+	}
+	p.nodes = append(p.nodes, n)
+	return p.flushFull(len(p.nodes))
+}
+
+// WriteWay writes way
+func (p *PBFWriter) WriteWay(w *Way) error {
+	if err := p.switchBlock(pbfWays); err != nil {
+		return err
+	}
+	p.ways = append(p.ways, w)
+	return p.flushFull(len(p.ways))
+}
+
+// WriteRelation writes relation
+func (p *PBFWriter) WriteRelation(r *Relation) error {
+	if err := p.switchBlock(pbfRelations); err != nil {
+		return err
+	}
+	p.relations = append(p.relations, r)
+	return p.flushFull(len(p.relations))
+}
+
+// WriteChangeset writes changeset. The pbf format keeps only changeset id.
+func (p *PBFWriter) WriteChangeset(c *Changeset) error {
+	if err := p.switchBlock(pbfChangesets); err != nil {
+		return err
+	}
+	p.changesets = append(p.changesets, c)
+	return p.flushFull(len(p.changesets))
+}
+
+// Close writes the rest of elements
+func (p *PBFWriter) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.writeHeader()
+}
+
+func (p *PBFWriter) switchBlock(kind int) error {
+	if p.kind == kind {
+		return nil
+	}
+	if err := p.flush(); err != nil {
+		return err
+	}
+	p.kind = kind
+	return nil
+}
+
+func (p *PBFWriter) flushFull(count int) error {
+	if count < pbfBlockSize {
+		return nil
+	}
+	return p.flush()
+}
+
+func (p *PBFWriter) flush() error {
+	if err := p.writeHeader(); err != nil {
+		return err
+	}
+
+	st := newStringTable()
+	var group protoBuffer
+	switch p.kind {
+	case pbfNodes:
+		if len(p.nodes) == 0 {
+			return nil
+		}
+		group.Bytes(2, encodeDenseNodes(p.nodes, st))
+		p.nodes = p.nodes[:0]
+	case pbfWays:
+		if len(p.ways) == 0 {
+			return nil
+		}
+		for _, w := range p.ways {
+			group.Bytes(3, encodeWay(w, st))
+		}
+		p.ways = p.ways[:0]
+	case pbfRelations:
+		if len(p.relations) == 0 {
+			return nil
+		}
+		for _, r := range p.relations {
+			group.Bytes(4, encodeRelation(r, st))
+		}
+		p.relations = p.relations[:0]
+	case pbfChangesets:
+		if len(p.changesets) == 0 {
+			return nil
+		}
+		for _, c := range p.changesets {
+			var cs protoBuffer
+			cs.Int(1, c.ID)
+			group.Bytes(5, cs)
+		}
+		p.changesets = p.changesets[:0]
+	default:
+		return nil
+	}
+
+	var block protoBuffer
+	block.Bytes(1, st.encode())
+	block.Bytes(2, group)
+	block.Int(17, pbfGranularity)
+	block.Int(18, pbfDateGranularity)
+	return p.writeBlob(pbfDataType, block)
+}
+
+func (p *PBFWriter) writeHeader() error {
+	if p.headerWritten {
+		return nil
+	}
+	p.headerWritten = true
+
+	var header protoBuffer
+	header.String(4, "OsmSchema-V0.6")
+	header.String(4, "DenseNodes")
+	if p.historical {
+		header.String(4, "HistoricalInformation")
+	}
+	header.String(16, Generator)
+	return p.writeBlob(pbfHeaderType, header)
+}
+
+func (p *PBFWriter) writeBlob(kind string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	var blob protoBuffer
+	blob.Int(2, int64(len(data)))
+	blob.Bytes(3, compressed.Bytes())
+
+	var header protoBuffer
+	header.String(1, kind)
+	header.Int(3, int64(len(blob)))
+
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(len(header)))
+	for _, b := range [][]byte{size, header, blob} {
+		if _, err := p.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stringTable collects strings of the primitive block,
+// the first string is always empty as required by the format.
+type stringTable struct {
+	index   map[string]int64
+	strings []string
+}
+
+func newStringTable() *stringTable {
+	return &stringTable{
+		index:   map[string]int64{"": 0},
+		strings: []string{""},
+	}
+}
+
+func (st *stringTable) id(s string) int64 {
+	if id, ok := st.index[s]; ok {
+		return id
+	}
+	id := int64(len(st.strings))
+	st.index[s] = id
+	st.strings = append(st.strings, s)
+	return id
+}
+
+func (st *stringTable) encode() []byte {
+	var b protoBuffer
+	for _, s := range st.strings {
+		b.String(1, s)
+	}
+	return b
+}
+
+func encodeDenseNodes(nodes Nodes, st *stringTable) []byte {
+	ids := make([]int64, 0, len(nodes))
+	lats := make([]int64, 0, len(nodes))
+	lons := make([]int64, 0, len(nodes))
+	keysVals := make([]int64, 0, len(nodes))
+
+	versions := make([]int64, 0, len(nodes))
+	timestamps := make([]int64, 0, len(nodes))
+	changesets := make([]int64, 0, len(nodes))
+	uids := make([]int64, 0, len(nodes))
+	userSids := make([]int64, 0, len(nodes))
+	visibles := make([]int64, 0, len(nodes))
+
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+		lats = append(lats, pbfCoordinate(n.Lat))
+		lons = append(lons, pbfCoordinate(n.Lon))
+		for _, t := range n.Tags {
+			keysVals = append(keysVals, st.id(t.K), st.id(t.V))
+		}
+		keysVals = append(keysVals, 0)
+
+		versions = append(versions, int64(n.Version))
+		timestamps = append(timestamps, pbfTimestamp(n.Timestamp))
+		changesets = append(changesets, n.ChangesetID)
+		uid, userSid := pbfUser(n.User, n.UserID, st)
+		uids = append(uids, uid)
+		userSids = append(userSids, userSid)
+		visibles = append(visibles, pbfBool(n.Visible))
+	}
+
+	var info protoBuffer
+	info.PackedInts(1, versions)
+	info.PackedDeltas(2, timestamps)
+	info.PackedDeltas(3, changesets)
+	info.PackedDeltas(4, uids)
+	info.PackedDeltas(5, userSids)
+	info.PackedInts(6, visibles)
+
+	var dense protoBuffer
+	dense.PackedDeltas(1, ids)
+	dense.Bytes(5, info)
+	dense.PackedDeltas(8, lats)
+	dense.PackedDeltas(9, lons)
+	dense.PackedInts(10, keysVals)
+	return dense
+}
+
+func encodeWay(w *Way, st *stringTable) []byte {
+	var way protoBuffer
+	way.Int(1, w.ID)
+	encodeTags(&way, w.Tags, st)
+	way.Bytes(4, encodeInfo(w.Version, w.Timestamp, w.ChangesetID, w.User, w.UserID, w.Visible, st))
+
+	refs := make([]int64, 0, len(w.Nodes))
+	for _, n := range w.Nodes {
+		refs = append(refs, n.ID)
+	}
+	way.PackedDeltas(8, refs)
+	return way
+}
+
+func encodeRelation(r *Relation, st *stringTable) []byte {
+	var rel protoBuffer
+	rel.Int(1, r.ID)
+	encodeTags(&rel, r.Tags, st)
+	rel.Bytes(4, encodeInfo(r.Version, r.Timestamp, r.ChangesetID, r.User, r.UserID, r.Visible, st))
+
+	roles := make([]int64, 0, len(r.Members))
+	memIDs := make([]int64, 0, len(r.Members))
+	types := make([]int64, 0, len(r.Members))
+	for _, m := range r.Members {
+		roles = append(roles, st.id(m.Role))
+		memIDs = append(memIDs, m.Ref)
+		types = append(types, pbfMemberTypes[m.Type])
+	}
+	rel.PackedInts(8, roles)
+	rel.PackedDeltas(9, memIDs)
+	rel.PackedInts(10, types)
+	return rel
+}
+
+func encodeTags(b *protoBuffer, tags Tags, st *stringTable) {
+	keys := make([]int64, 0, len(tags))
+	vals := make([]int64, 0, len(tags))
+	for _, t := range tags {
+		keys = append(keys, st.id(t.K))
+		vals = append(vals, st.id(t.V))
+	}
+	b.PackedInts(2, keys)
+	b.PackedInts(3, vals)
+}
+
+func encodeInfo(version int, timestamp Time, changeset int64, user *string, userID *int64, visible bool, st *stringTable) []byte {
+	var info protoBuffer
+	info.Int(1, int64(version))
+	info.Int(2, pbfTimestamp(timestamp))
+	info.Int(3, changeset)
+	if user != nil && userID != nil {
+		info.Int(4, *userID)
+		info.Int(5, st.id(*user))
+	}
+	info.Bool(6, visible)
+	return info
+}
+
+// pbfCoordinate converts degrees to granularity units
+func pbfCoordinate(c *float64) int64 {
+	if c == nil {
+		return 0
+	}
+	return roundCoordinate(*c * 1e9 / pbfGranularity)
+}
+
+// pbfTimestamp converts time to date granularity units
+func pbfTimestamp(t Time) int64 {
+	return time.Time(t).UnixNano() / int64(time.Millisecond) / pbfDateGranularity
+}
+
+// pbfUser returns uid and user string id, anonymous users are encoded as 0
+func pbfUser(user *string, userID *int64, st *stringTable) (int64, int64) {
+	if user == nil || userID == nil {
+		return 0, 0
+	}
+	return *userID, st.id(*user)
+}
+
+func pbfBool(v bool) int64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func roundCoordinate(v float64) int64 {
+	if v < 0 {
+		return int64(v - 0.5)
+	}
+	return int64(v + 0.5)
+}
@@ -0,0 +1,650 @@
+package osm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	maxBlobHeaderSize = 64 * 1024
+	maxBlobSize       = 32 * 1024 * 1024
+)
+
+var pbfSupportedFeatures = map[string]bool{
+	"OsmSchema-V0.6":        true,
+	"DenseNodes":            true,
+	"HistoricalInformation": true,
+}
+
+var pbfMemberTypeNames = map[int64]string{
+	0: "node",
+	1: "way",
+	2: "relation",
+}
+
+// PBFScanner reads elements of osm pbf file. Only one primitive block
+// is decoded at a time, so the memory doesn't depend on the file size.
+type PBFScanner struct {
+	r io.Reader
+	c io.Closer
+
+	headerRead bool
+	objects    Objects
+	object     Object
+	err        error
+}
+
+// NewPBFScanner returns scanner for osm pbf file
+func NewPBFScanner(r io.Reader) *PBFScanner {
+	s := &PBFScanner{r: r}
+	if c, ok := r.(io.Closer); ok {
+		s.c = c
+	}
+	return s
+}
+
+// Scan reads the next element, false is returned at the end of the file or on error
+func (s *PBFScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	s.object = nil
+
+	for len(s.objects) == 0 {
+		kind, data, err := s.readBlob()
+		if err == io.EOF {
+			if !s.headerRead {
+				s.err = errors.New("osm: missing pbf header block")
+			}
+			return false
+		}
+		if err != nil {
+			s.err = err
+			return false
+		}
+
+		switch kind {
+		case pbfHeaderType:
+			s.err = s.readHeader(data)
+			s.headerRead = true
+		case pbfDataType:
+			if !s.headerRead {
+				s.err = errors.New("osm: pbf data block before header block")
+				return false
+			}
+			s.objects, s.err = decodePrimitiveBlock(data)
+		}
+		if s.err != nil {
+			return false
+		}
+	}
+
+	s.object = s.objects[0]
+	s.objects[0] = nil
+	s.objects = s.objects[1:]
+	return true
+}
+
+// Object returns the last read element
+func (s *PBFScanner) Object() Object {
+	return s.object
+}
+
+// Err returns the first error, the end of the file isn't an error
+func (s *PBFScanner) Err() error {
+	return s.err
+}
+
+// Close closes the underlying reader if it's closer
+func (s *PBFScanner) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+func (s *PBFScanner) readBlob() (string, []byte, error) {
+	size := make([]byte, 4)
+	if _, err := io.ReadFull(s.r, size); err != nil {
+		return "", nil, err
+	}
+	headerSize := binary.BigEndian.Uint32(size)
+	if headerSize > maxBlobHeaderSize {
+		return "", nil, fmt.Errorf("osm: pbf blob header is too big: %v", headerSize)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(s.r, header); err != nil {
+		return "", nil, unexpectedEOF(err)
+	}
+	var kind string
+	var dataSize int64
+	hr := newProtoReader(header)
+	for hr.Next() {
+		switch hr.field {
+		case 1:
+			kind = hr.Text()
+		case 3:
+			dataSize = hr.Int()
+		default:
+			hr.Skip()
+		}
+	}
+	if err := hr.Err(); err != nil {
+		return "", nil, err
+	}
+	if dataSize < 0 || dataSize > maxBlobSize {
+		return "", nil, fmt.Errorf("osm: pbf blob is too big: %v", dataSize)
+	}
+
+	blob := make([]byte, dataSize)
+	if _, err := io.ReadFull(s.r, blob); err != nil {
+		return "", nil, unexpectedEOF(err)
+	}
+	data, err := decodeBlob(blob)
+	return kind, data, err
+}
+
+func (s *PBFScanner) readHeader(data []byte) error {
+	hr := newProtoReader(data)
+	for hr.Next() {
+		switch hr.field {
+		case 4:
+			if f := hr.Text(); !pbfSupportedFeatures[f] {
+				return fmt.Errorf("osm: unsupported pbf feature: %v", f)
+			}
+		default:
+			hr.Skip()
+		}
+	}
+	return hr.Err()
+}
+
+func decodeBlob(blob []byte) ([]byte, error) {
+	var raw, compressed []byte
+	var rawSize int64
+	br := newProtoReader(blob)
+	for br.Next() {
+		switch br.field {
+		case 1:
+			raw = br.Bytes()
+		case 2:
+			rawSize = br.Int()
+		case 3:
+			compressed = br.Bytes()
+		default:
+			br.Skip()
+		}
+	}
+	if err := br.Err(); err != nil {
+		return nil, err
+	}
+	if raw != nil {
+		return raw, nil
+	}
+	if compressed == nil {
+		return nil, errors.New("osm: unsupported pbf blob compression")
+	}
+	if rawSize < 0 || rawSize > maxBlobSize {
+		return nil, fmt.Errorf("osm: pbf blob is too big: %v", rawSize)
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(zr, maxBlobSize))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != rawSize {
+		return nil, fmt.Errorf("osm: pbf blob size mismatch: %v != %v", len(data), rawSize)
+	}
+	return data, nil
+}
+
+// primitiveBlock keeps the state required to decode elements of the block
+type primitiveBlock struct {
+	strings         []string
+	granularity     int64
+	latOffset       int64
+	lonOffset       int64
+	dateGranularity int64
+}
+
+func (b *primitiveBlock) str(id int64) (string, error) {
+	if id < 0 || id >= int64(len(b.strings)) {
+		return "", fmt.Errorf("osm: pbf string id out of range: %v", id)
+	}
+	return b.strings[id], nil
+}
+
+func (b *primitiveBlock) coordinate(offset, v int64) *float64 {
+	c := float64(offset+b.granularity*v) / 1e9
+	return &c
+}
+
+func (b *primitiveBlock) timestamp(v int64) Time {
+	return Time(time.Unix(0, v*b.dateGranularity*int64(time.Millisecond)).UTC())
+}
+
+func decodePrimitiveBlock(data []byte) (Objects, error) {
+	b := &primitiveBlock{
+		granularity:     pbfGranularity,
+		dateGranularity: pbfDateGranularity,
+	}
+
+	// the string table may follow the groups, so groups are decoded after the block header
+	var groups [][]byte
+	r := newProtoReader(data)
+	for r.Next() {
+		switch r.field {
+		case 1:
+			st := newProtoReader(r.Bytes())
+			for st.Next() {
+				if st.field != 1 {
+					st.Skip()
+					continue
+				}
+				b.strings = append(b.strings, st.Text())
+			}
+			if err := st.Err(); err != nil {
+				return nil, err
+			}
+		case 2:
+			groups = append(groups, r.Bytes())
+		case 17:
+			b.granularity = r.Int()
+		case 18:
+			b.dateGranularity = r.Int()
+		case 19:
+			b.latOffset = r.Int()
+		case 20:
+			b.lonOffset = r.Int()
+		default:
+			r.Skip()
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	var objects Objects
+	for _, group := range groups {
+		gr := newProtoReader(group)
+		for gr.Next() {
+			var err error
+			switch gr.field {
+			case 1:
+				var n *Node
+				n, err = b.decodeNode(gr.Bytes())
+				objects = append(objects, n)
+			case 2:
+				var ns Nodes
+				ns, err = b.decodeDenseNodes(gr.Bytes())
+				for _, n := range ns {
+					objects = append(objects, n)
+				}
+			case 3:
+				var w *Way
+				w, err = b.decodeWay(gr.Bytes())
+				objects = append(objects, w)
+			case 4:
+				var rel *Relation
+				rel, err = b.decodeRelation(gr.Bytes())
+				objects = append(objects, rel)
+			case 5:
+				c := &Changeset{}
+				cr := newProtoReader(gr.Bytes())
+				for cr.Next() {
+					if cr.field == 1 {
+						c.ID = cr.Int()
+						continue
+					}
+					cr.Skip()
+				}
+				err = cr.Err()
+				objects = append(objects, c)
+			default:
+				gr.Skip()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := gr.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// elementInfo contains metadata shared by all element types
+type elementInfo struct {
+	Version     int
+	Timestamp   Time
+	ChangesetID int64
+	User        *string
+	UserID      *int64
+	Visible     bool
+}
+
+func (b *primitiveBlock) decodeInfo(data []byte) (elementInfo, error) {
+	info := elementInfo{Visible: true}
+	var uid, userSid int64
+	hasUser := false
+	r := newProtoReader(data)
+	for r.Next() {
+		switch r.field {
+		case 1:
+			info.Version = int(r.Int())
+		case 2:
+			info.Timestamp = b.timestamp(r.Int())
+		case 3:
+			info.ChangesetID = r.Int()
+		case 4:
+			uid = r.Int()
+			hasUser = true
+		case 5:
+			userSid = r.Int()
+			hasUser = true
+		case 6:
+			info.Visible = r.Bool()
+		default:
+			r.Skip()
+		}
+	}
+	if err := r.Err(); err != nil {
+		return info, err
+	}
+	if hasUser {
+		user, err := b.str(userSid)
+		if err != nil {
+			return info, err
+		}
+		info.User, info.UserID = &user, &uid
+	}
+	return info, nil
+}
+
+func (b *primitiveBlock) decodeTags(keys, vals []int64) (Tags, error) {
+	if len(keys) != len(vals) {
+		return nil, errors.New("osm: pbf keys and values mismatch")
+	}
+	var tags Tags
+	for i := range keys {
+		k, err := b.str(keys[i])
+		if err != nil {
+			return nil, err
+		}
+		v, err := b.str(vals[i])
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, &Tag{K: k, V: v})
+	}
+	return tags, nil
+}
+
+func (b *primitiveBlock) decodeNode(data []byte) (*Node, error) {
+	n := &Node{Visible: true}
+	var keys, vals []int64
+	var lat, lon int64
+	r := newProtoReader(data)
+	for r.Next() {
+		switch r.field {
+		case 1:
+			n.ID = r.Sint()
+		case 2:
+			keys = r.PackedInts()
+		case 3:
+			vals = r.PackedInts()
+		case 4:
+			info, err := b.decodeInfo(r.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			n.Version, n.Timestamp, n.ChangesetID = info.Version, info.Timestamp, info.ChangesetID
+			n.User, n.UserID, n.Visible = info.User, info.UserID, info.Visible
+		case 8:
+			lat = r.Sint()
+		case 9:
+			lon = r.Sint()
+		default:
+			r.Skip()
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	n.Tags, err = b.decodeTags(keys, vals)
+	if err != nil {
+		return nil, err
+	}
+	if n.Visible {
+		n.Lat, n.Lon = b.coordinate(b.latOffset, lat), b.coordinate(b.lonOffset, lon)
+	}
+	return n, nil
+}
+
+func (b *primitiveBlock) decodeDenseNodes(data []byte) (Nodes, error) {
+	var ids, lats, lons, keysVals []int64
+	var versions, timestamps, changesets, uids, userSids, visibles []int64
+	hasInfo := false
+
+	r := newProtoReader(data)
+	for r.Next() {
+		switch r.field {
+		case 1:
+			ids = r.PackedDeltas()
+		case 5:
+			hasInfo = true
+			ir := newProtoReader(r.Bytes())
+			for ir.Next() {
+				switch ir.field {
+				case 1:
+					versions = ir.PackedInts()
+				case 2:
+					timestamps = ir.PackedDeltas()
+				case 3:
+					changesets = ir.PackedDeltas()
+				case 4:
+					uids = ir.PackedDeltas()
+				case 5:
+					userSids = ir.PackedDeltas()
+				case 6:
+					visibles = ir.PackedInts()
+				default:
+					ir.Skip()
+				}
+			}
+			if err := ir.Err(); err != nil {
+				return nil, err
+			}
+		case 8:
+			lats = r.PackedDeltas()
+		case 9:
+			lons = r.PackedDeltas()
+		case 10:
+			keysVals = r.PackedInts()
+		default:
+			r.Skip()
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	count := len(ids)
+	if len(lats) != count || len(lons) != count {
+		return nil, errors.New("osm: pbf dense nodes length mismatch")
+	}
+	if hasInfo {
+		for _, l := range [][]int64{versions, timestamps, changesets} {
+			if len(l) != count {
+				return nil, errors.New("osm: pbf dense info length mismatch")
+			}
+		}
+		for _, l := range [][]int64{uids, userSids, visibles} {
+			if len(l) != 0 && len(l) != count {
+				return nil, errors.New("osm: pbf dense info length mismatch")
+			}
+		}
+	}
+
+	nodes := make(Nodes, 0, count)
+	for i := 0; i < count; i++ {
+		n := &Node{ID: ids[i], Visible: true}
+		if hasInfo {
+			n.Version = int(versions[i])
+			n.Timestamp = b.timestamp(timestamps[i])
+			n.ChangesetID = changesets[i]
+			if len(visibles) != 0 {
+				n.Visible = visibles[i] != 0
+			}
+			if len(uids) != 0 && len(userSids) != 0 && (uids[i] != 0 || userSids[i] != 0) {
+				user, err := b.str(userSids[i])
+				if err != nil {
+					return nil, err
+				}
+				uid := uids[i]
+				n.User, n.UserID = &user, &uid
+			}
+		}
+		if n.Visible {
+			n.Lat, n.Lon = b.coordinate(b.latOffset, lats[i]), b.coordinate(b.lonOffset, lons[i])
+		}
+		nodes = append(nodes, n)
+	}
+
+	// keys_vals is a list of key, value pairs of every node terminated by 0
+	if len(keysVals) != 0 {
+		i := 0
+		for _, n := range nodes {
+			for i < len(keysVals) && keysVals[i] != 0 {
+				if i+1 >= len(keysVals) {
+					return nil, errors.New("osm: pbf dense keys and values mismatch")
+				}
+				k, err := b.str(keysVals[i])
+				if err != nil {
+					return nil, err
+				}
+				v, err := b.str(keysVals[i+1])
+				if err != nil {
+					return nil, err
+				}
+				n.Tags = append(n.Tags, &Tag{K: k, V: v})
+				i += 2
+			}
+			i++
+		}
+	}
+
+	return nodes, nil
+}
+
+func (b *primitiveBlock) decodeWay(data []byte) (*Way, error) {
+	w := &Way{Visible: true}
+	var keys, vals []int64
+	r := newProtoReader(data)
+	for r.Next() {
+		switch r.field {
+		case 1:
+			w.ID = r.Int()
+		case 2:
+			keys = r.PackedInts()
+		case 3:
+			vals = r.PackedInts()
+		case 4:
+			info, err := b.decodeInfo(r.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			w.Version, w.Timestamp, w.ChangesetID = info.Version, info.Timestamp, info.ChangesetID
+			w.User, w.UserID, w.Visible = info.User, info.UserID, info.Visible
+		case 8:
+			for _, ref := range r.PackedDeltas() {
+				w.Nodes = append(w.Nodes, wayNode{ID: ref})
+			}
+		default:
+			r.Skip()
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	w.Tags, err = b.decodeTags(keys, vals)
+	return w, err
+}
+
+func (b *primitiveBlock) decodeRelation(data []byte) (*Relation, error) {
+	rel := &Relation{Visible: true}
+	var keys, vals, roles, memIDs, types []int64
+	r := newProtoReader(data)
+	for r.Next() {
+		switch r.field {
+		case 1:
+			rel.ID = r.Int()
+		case 2:
+			keys = r.PackedInts()
+		case 3:
+			vals = r.PackedInts()
+		case 4:
+			info, err := b.decodeInfo(r.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			rel.Version, rel.Timestamp, rel.ChangesetID = info.Version, info.Timestamp, info.ChangesetID
+			rel.User, rel.UserID, rel.Visible = info.User, info.UserID, info.Visible
+		case 8:
+			roles = r.PackedInts()
+		case 9:
+			memIDs = r.PackedDeltas()
+		case 10:
+			types = r.PackedInts()
+		default:
+			r.Skip()
+		}
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if len(roles) != len(memIDs) || len(types) != len(memIDs) {
+		return nil, errors.New("osm: pbf relation members mismatch")
+	}
+
+	for i := range memIDs {
+		role, err := b.str(roles[i])
+		if err != nil {
+			return nil, err
+		}
+		typ, ok := pbfMemberTypeNames[types[i]]
+		if !ok {
+			return nil, fmt.Errorf("osm: unknown pbf member type: %v", types[i])
+		}
+		rel.Members = append(rel.Members, Member{Type: typ, Ref: memIDs[i], Role: role})
+	}
+
+	var err error
+	rel.Tags, err = b.decodeTags(keys, vals)
+	return rel, err
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package osm
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// pbfFeatures returns the required features of the pbf header
+func pbfFeatures(t *testing.T, data []byte) []string {
+	kind, header, err := NewPBFScanner(bytes.NewReader(data)).readBlob()
+	if err != nil || kind != pbfHeaderType {
+		t.Fatalf("header error: %v %v", kind, err)
+	}
+	var features []string
+	r := newProtoReader(header)
+	for r.Next() {
+		if r.field == 4 {
+			features = append(features, r.Text())
+		} else {
+			r.Skip()
+		}
+	}
+	return features
+}
+
+func TestPBFWriterHeader(t *testing.T) {
+	for _, historical := range []bool{false, true} {
+		buf := &bytes.Buffer{}
+		w := NewPBFWriter(buf, historical)
+		if err := testOSM().Stream(w); err != nil {
+			t.Fatalf("stream error: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("close error: %v", err)
+		}
+
+		expected := []string{"OsmSchema-V0.6", "DenseNodes"}
+		if historical {
+			expected = append(expected, "HistoricalInformation")
+		}
+		if features := pbfFeatures(t, buf.Bytes()); !reflect.DeepEqual(features, expected) {
+			t.Errorf("incorrect features %v, expected %v", features, expected)
+		}
+	}
+}
+
+func TestEncodeDenseNodes(t *testing.T) {
+	lat, lon := 51.5, -0.25
+	user, uid := "alice", int64(7)
+	nodes := Nodes{
+		{ID: 10, Lat: &lat, Lon: &lon, Visible: true, Version: 1, ChangesetID: 100, User: &user, UserID: &uid,
+			Tags: Tags{{K: "amenity", V: "cafe"}}},
+		{ID: 12, Lat: &lat, Lon: &lon, Visible: true, Version: 2, ChangesetID: 103},
+		{ID: 9, Lat: &lat, Lon: &lon, Visible: false, Version: 3, ChangesetID: 103},
+	}
+	st := newStringTable()
+	r := newProtoReader(encodeDenseNodes(nodes, st))
+
+	var ids, lats, keysVals, changesets, visibles []int64
+	for r.Next() {
+		switch r.field {
+		case 1:
+			ids = r.PackedSints()
+		case 5:
+			ir := newProtoReader(r.Bytes())
+			for ir.Next() {
+				switch ir.field {
+				case 3:
+					changesets = ir.PackedSints()
+				case 6:
+					visibles = ir.PackedInts()
+				default:
+					ir.Skip()
+				}
+			}
+		case 8:
+			lats = r.PackedSints()
+		case 10:
+			keysVals = r.PackedInts()
+		default:
+			r.Skip()
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+
+	// ids, coordinates and metadata are delta coded
+	checks := []struct {
+		name             string
+		values, expected []int64
+	}{
+		{"ids", ids, []int64{10, 2, -3}},
+		{"lats", lats, []int64{515000000, 0, 0}},
+		{"changesets", changesets, []int64{100, 3, 0}},
+		{"visibles", visibles, []int64{1, 1, 0}},
+		{"tags", keysVals, []int64{st.index["amenity"], st.index["cafe"], 0, 0, 0}},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.values, c.expected) {
+			t.Errorf("incorrect %v %v, expected %v", c.name, c.values, c.expected)
+		}
+	}
+	if st.strings[0] != "" || st.index["alice"] == 0 {
+		t.Errorf("incorrect string table: %v", st.strings)
+	}
+}
@@ -0,0 +1,255 @@
+package osm
+
+import "errors"
+
+// protoBuffer is a minimal protocol buffers encoder. It covers the wire types
+// used by the osm pbf and vector tile formats, so no generated code is needed.
+type protoBuffer []byte
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errProtobuf is returned when protocol buffers message is malformed
+var errProtobuf = errors.New("malformed protobuf message")
+
+func (p *protoBuffer) key(field, wire int) {
+	p.uvarint(uint64(field)<<3 | uint64(wire))
+}
+
+func (p *protoBuffer) uvarint(v uint64) {
+	for v >= 0x80 {
+		*p = append(*p, byte(v)|0x80)
+		v >>= 7
+	}
+	*p = append(*p, byte(v))
+}
+
+// Uint writes unsigned varint field
+func (p *protoBuffer) Uint(field int, v uint64) {
+	p.key(field, wireVarint)
+	p.uvarint(v)
+}
+
+// Int writes int32/int64 varint field
+func (p *protoBuffer) Int(field int, v int64) {
+	p.key(field, wireVarint)
+	p.uvarint(uint64(v))
+}
+
+// Sint writes sint32/sint64 zigzag encoded field
+func (p *protoBuffer) Sint(field int, v int64) {
+	p.key(field, wireVarint)
+	p.uvarint(zigzag(v))
+}
+
+// Bool writes bool field
+func (p *protoBuffer) Bool(field int, v bool) {
+	if v {
+		p.Uint(field, 1)
+		return
+	}
+	p.Uint(field, 0)
+}
+
+// Bytes writes length delimited field
+func (p *protoBuffer) Bytes(field int, b []byte) {
+	p.key(field, wireBytes)
+	p.uvarint(uint64(len(b)))
+	*p = append(*p, b...)
+}
+
+// String writes string field
+func (p *protoBuffer) String(field int, s string) {
+	p.key(field, wireBytes)
+	p.uvarint(uint64(len(s)))
+	*p = append(*p, s...)
+}
+
+// PackedInts writes packed repeated int32/int64 field
+func (p *protoBuffer) PackedInts(field int, vs []int64) {
+	if len(vs) == 0 {
+		return
+	}
+	var packed protoBuffer
+	for _, v := range vs {
+		packed.uvarint(uint64(v))
+	}
+	p.Bytes(field, packed)
+}
+
+// PackedSints writes packed repeated sint32/sint64 field
+func (p *protoBuffer) PackedSints(field int, vs []int64) {
+	if len(vs) == 0 {
+		return
+	}
+	var packed protoBuffer
+	for _, v := range vs {
+		packed.uvarint(zigzag(v))
+	}
+	p.Bytes(field, packed)
+}
+
+// PackedDeltas writes packed repeated sint64 field using delta coding
+func (p *protoBuffer) PackedDeltas(field int, vs []int64) {
+	if len(vs) == 0 {
+		return
+	}
+	var packed protoBuffer
+	var prev int64
+	for _, v := range vs {
+		packed.uvarint(zigzag(v - prev))
+		prev = v
+	}
+	p.Bytes(field, packed)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// protoReader is a minimal protocol buffers decoder, the counterpart of protoBuffer.
+type protoReader struct {
+	buf   []byte
+	field int
+	wire  int
+	err   error
+}
+
+func newProtoReader(b []byte) *protoReader {
+	return &protoReader{buf: b}
+}
+
+// Next reads the key of the next field
+func (p *protoReader) Next() bool {
+	if p.err != nil || len(p.buf) == 0 {
+		return false
+	}
+	key := p.uvarint()
+	p.field, p.wire = int(key>>3), int(key&7)
+	return p.err == nil
+}
+
+// Err returns the first decoding error
+func (p *protoReader) Err() error {
+	return p.err
+}
+
+func (p *protoReader) uvarint() uint64 {
+	var v uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if len(p.buf) == 0 {
+			break
+		}
+		b := p.buf[0]
+		p.buf = p.buf[1:]
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v
+		}
+	}
+	p.err = errProtobuf
+	return 0
+}
+
+// Uint reads unsigned varint value
+func (p *protoReader) Uint() uint64 {
+	return p.uvarint()
+}
+
+// Int reads int32/int64 varint value
+func (p *protoReader) Int() int64 {
+	return int64(p.uvarint())
+}
+
+// Sint reads sint32/sint64 zigzag encoded value
+func (p *protoReader) Sint() int64 {
+	return unzigzag(p.uvarint())
+}
+
+// Bool reads bool value
+func (p *protoReader) Bool() bool {
+	return p.uvarint() != 0
+}
+
+// Bytes reads length delimited value
+func (p *protoReader) Bytes() []byte {
+	l := p.uvarint()
+	if p.err != nil {
+		return nil
+	}
+	if uint64(len(p.buf)) < l {
+		p.err = errProtobuf
+		return nil
+	}
+	b := p.buf[:l]
+	p.buf = p.buf[l:]
+	return b
+}
+
+// Text reads string value
+func (p *protoReader) Text() string {
+	return string(p.Bytes())
+}
+
+// PackedInts reads packed repeated int32/int64 value
+func (p *protoReader) PackedInts() []int64 {
+	packed := newProtoReader(p.Bytes())
+	var vs []int64
+	for len(packed.buf) > 0 && packed.err == nil {
+		vs = append(vs, packed.Int())
+	}
+	if packed.err != nil {
+		p.err = packed.err
+	}
+	return vs
+}
+
+// PackedSints reads packed repeated sint32/sint64 value
+func (p *protoReader) PackedSints() []int64 {
+	vs := p.PackedInts()
+	for i := range vs {
+		vs[i] = unzigzag(uint64(vs[i]))
+	}
+	return vs
+}
+
+// PackedDeltas reads packed repeated delta coded sint64 value
+func (p *protoReader) PackedDeltas() []int64 {
+	vs := p.PackedSints()
+	for i := 1; i < len(vs); i++ {
+		vs[i] += vs[i-1]
+	}
+	return vs
+}
+
+// Skip skips the value of the current field
+func (p *protoReader) Skip() {
+	switch p.wire {
+	case wireVarint:
+		p.uvarint()
+	case wireFixed64:
+		p.skipBytes(8)
+	case wireBytes:
+		p.Bytes()
+	case wireFixed32:
+		p.skipBytes(4)
+	default:
+		p.err = errProtobuf
+	}
+}
+
+func (p *protoReader) skipBytes(n int) {
+	if len(p.buf) < n {
+		p.err = errProtobuf
+		return
+	}
+	p.buf = p.buf[n:]
+}
@@ -0,0 +1,70 @@
+package osm
+
+import "math"
+
+// quadTileBits is the number of bits of each axis in the quadtile
+const quadTileBits = 16
+
+// QuadTile returns the quadtile of the coordinates as it's stored in
+// the tile column of the osm database, 16 bits of longitude and latitude
+// interleaved with longitude bits first.
+func QuadTile(lat, lon float64) uint32 {
+	return xy2tile(lon2x(lon), lat2y(lat))
+}
+
+func lon2x(lon float64) uint32 {
+	return uint32(math.Round((lon + 180) * 65535 / 360))
+}
+
+func lat2y(lat float64) uint32 {
+	return uint32(math.Round((lat + 90) * 65535 / 180))
+}
+
+func xy2tile(x, y uint32) uint32 {
+	var tile uint32
+	for i := quadTileBits - 1; i >= 0; i-- {
+		tile = tile<<1 | (x>>uint(i))&1
+		tile = tile<<1 | (y>>uint(i))&1
+	}
+	return tile
+}
+
+// TileRange is an inclusive range of quadtiles
+type TileRange struct {
+	Min, Max uint32
+}
+
+// TileRanges returns sorted quadtile ranges covering the bounds.
+// Nodes inside the bounds are in these ranges, but the ranges
+// may contain nodes just outside the bounds too.
+func TileRanges(b *Bounds) []TileRange {
+	minX, maxX := lon2x(b.MinLon), lon2x(b.MaxLon)
+	minY, maxY := lat2y(b.MinLat), lat2y(b.MaxLat)
+
+	var ranges []TileRange
+	// visit walks the quadtree, every square of the tree is a continuous range of tiles
+	var visit func(x, y, size uint32)
+	visit = func(x, y, size uint32) {
+		lastX, lastY := x+size-1, y+size-1
+		if x > maxX || lastX < minX || y > maxY || lastY < minY {
+			return
+		}
+		if x >= minX && lastX <= maxX && y >= minY && lastY <= maxY {
+			first := xy2tile(x, y)
+			last := uint32(uint64(first) + uint64(size)*uint64(size) - 1)
+			if n := len(ranges); n != 0 && uint64(ranges[n-1].Max)+1 == uint64(first) {
+				ranges[n-1].Max = last
+			} else {
+				ranges = append(ranges, TileRange{Min: first, Max: last})
+			}
+			return
+		}
+		half := size / 2
+		visit(x, y, half)
+		visit(x, y+half, half)
+		visit(x+half, y, half)
+		visit(x+half, y+half, half)
+	}
+	visit(0, 0, 1<<quadTileBits)
+	return ranges
+}
@@ -0,0 +1,58 @@
+package osm
+
+import "testing"
+
+func TestQuadTile(t *testing.T) {
+	cases := []struct {
+		name     string
+		lat, lon float64
+		expected uint32
+	}{
+		{name: "south west", lat: -90, lon: -180, expected: 0},
+		{name: "north east", lat: 90, lon: 180, expected: 0xffffffff},
+		{name: "north west", lat: 90, lon: -180, expected: 0x55555555},
+		{name: "south east", lat: -90, lon: 180, expected: 0xaaaaaaaa},
+		{name: "center", lat: 0, lon: 0, expected: 0xc0000000},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := QuadTile(tc.lat, tc.lon); v != tc.expected {
+				t.Errorf("incorrect tile %x, expected %x", v, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTileRanges(t *testing.T) {
+	b := &Bounds{MinLat: 51.45, MinLon: -0.15, MaxLat: 51.55, MaxLon: -0.05}
+	ranges := TileRanges(b)
+
+	// all tiles of the bounds are covered and no other tiles are
+	expected := map[uint32]bool{}
+	for x := lon2x(b.MinLon); x <= lon2x(b.MaxLon); x++ {
+		for y := lat2y(b.MinLat); y <= lat2y(b.MaxLat); y++ {
+			expected[xy2tile(x, y)] = true
+		}
+	}
+	count := 0
+	for i, r := range ranges {
+		if i > 0 && r.Min <= ranges[i-1].Max+1 {
+			t.Errorf("ranges aren't sorted and merged: %v %v", ranges[i-1], r)
+		}
+		for tile := r.Min; tile <= r.Max; tile++ {
+			if !expected[tile] {
+				t.Errorf("unexpected tile %x", tile)
+			}
+			count++
+		}
+	}
+	if count != len(expected) {
+		t.Errorf("incorrect number of tiles %v, expected %v", count, len(expected))
+	}
+
+	world := TileRanges(&Bounds{MinLat: -90, MinLon: -180, MaxLat: 90, MaxLon: 180})
+	if len(world) != 1 || world[0] != (TileRange{0, 0xffffffff}) {
+		t.Errorf("incorrect world ranges: %v", world)
+	}
+}
@@ -13,6 +13,11 @@ func (x xmlNameJSONTypeRel) MarshalJSON() ([]byte, error) {
 	return []byte(`"relation"`), nil
 }
 
+// UnmarshalJSON ignores the type, it's known from the struct type
+func (x *xmlNameJSONTypeRel) UnmarshalJSON(b []byte) error {
+	return nil
+}
+
 // Relation is an collection of nodes, ways and other relations
 // with some defining attributes.
 type Relation struct {
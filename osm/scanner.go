@@ -0,0 +1,194 @@
+package osm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Scanner reads osm elements one by one, so files of any size
+// can be processed with constant memory.
+//
+//	for s.Scan() {
+//		o := s.Object()
+//	}
+//	if err := s.Err(); err != nil {
+//	}
+type Scanner interface {
+	Scan() bool
+	Object() Object
+	Err() error
+	Close() error
+}
+
+// Copy writes all elements read by the scanner to w
+func Copy(w Writer, s Scanner) error {
+	for s.Scan() {
+		if err := writeObject(w, s.Object()); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+func writeObject(w Writer, o Object) error {
+	switch o := o.(type) {
+	case *Node:
+		return w.WriteNode(o)
+	case *Way:
+		return w.WriteWay(o)
+	case *Relation:
+		return w.WriteRelation(o)
+	case *Changeset:
+		return w.WriteChangeset(o)
+	}
+	return nil
+}
+
+// osmChange actions
+const (
+	ActionCreate = "create"
+	ActionModify = "modify"
+	ActionDelete = "delete"
+)
+
+// XMLScanner reads elements of osm xml or osmChange documents
+type XMLScanner struct {
+	d      *xml.Decoder
+	c      io.Closer
+	root   string
+	action string
+
+	started bool
+	object  Object
+	err     error
+}
+
+// NewXMLScanner returns scanner for osm xml document
+func NewXMLScanner(r io.Reader) *XMLScanner {
+	return newXMLScanner(r, "osm")
+}
+
+// NewChangeScanner returns scanner for osmChange document.
+// Action of the current element is returned by Action method.
+func NewChangeScanner(r io.Reader) *XMLScanner {
+	return newXMLScanner(r, "osmChange")
+}
+
+func newXMLScanner(r io.Reader, root string) *XMLScanner {
+	s := &XMLScanner{
+		d:    xml.NewDecoder(r),
+		root: root,
+	}
+	if c, ok := r.(io.Closer); ok {
+		s.c = c
+	}
+	return s
+}
+
+// Scan reads the next element, false is returned at the end of the document or on error
+func (s *XMLScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	s.object = nil
+
+	for {
+		t, err := s.d.Token()
+		if err == io.EOF {
+			if !s.started {
+				s.err = fmt.Errorf("osm: missing <%v> element", s.root)
+			}
+			return false
+		}
+		if err != nil {
+			s.err = err
+			return false
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			if !s.started {
+				if t.Name.Local != s.root {
+					s.err = fmt.Errorf("osm: expected <%v> element, got <%v>", s.root, t.Name.Local)
+					return false
+				}
+				s.started = true
+				continue
+			}
+
+			var o Object
+			switch t.Name.Local {
+			case "node":
+				o = &Node{Visible: true}
+			case "way":
+				o = &Way{Visible: true}
+			case "relation":
+				o = &Relation{Visible: true}
+			case "changeset":
+				o = &Changeset{}
+			case ActionCreate, ActionModify, ActionDelete:
+				if s.root == "osmChange" {
+					s.action = t.Name.Local
+					continue
+				}
+				fallthrough
+			default:
+				if err := s.d.Skip(); err != nil {
+					s.err = err
+					return false
+				}
+				continue
+			}
+
+			if err := s.d.DecodeElement(o, &t); err != nil {
+				s.err = err
+				return false
+			}
+			if s.action == ActionDelete {
+				setVisible(o, false)
+			}
+			s.object = o
+			return true
+		case xml.EndElement:
+			switch t.Name.Local {
+			case ActionCreate, ActionModify, ActionDelete:
+				s.action = ""
+			}
+		}
+	}
+}
+
+// Object returns the last read element
+func (s *XMLScanner) Object() Object {
+	return s.object
+}
+
+// Action returns osmChange action of the last read element
+func (s *XMLScanner) Action() string {
+	return s.action
+}
+
+// Err returns the first error, the end of the document isn't an error
+func (s *XMLScanner) Err() error {
+	return s.err
+}
+
+// Close closes the underlying reader if it's closer
+func (s *XMLScanner) Close() error {
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+func setVisible(o Object, visible bool) {
+	switch o := o.(type) {
+	case *Node:
+		o.Visible = visible
+	case *Way:
+		o.Visible = visible
+	case *Relation:
+		o.Visible = visible
+	}
+}
@@ -0,0 +1,155 @@
+package osm
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXMLScanner(t *testing.T) {
+	expected := testOSM()
+	data, err := xml.Marshal(expected)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	o := New()
+	if err := Copy(o, NewXMLScanner(bytes.NewReader(data))); err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+
+	if !reflect.DeepEqual(o.Objects(), expected.Objects()) {
+		t.Errorf("incorrect elements: %v", o.Objects())
+	}
+}
+
+func TestXMLScannerErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{name: "empty", data: ``},
+		{name: "wrong root", data: `<osmChange></osmChange>`},
+		{name: "truncated", data: `<osm><node id="1" lat="1" lon="1">`},
+		{name: "invalid attribute", data: `<osm><node id="x"/></osm>`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewXMLScanner(strings.NewReader(tc.data))
+			for s.Scan() {
+			}
+			if s.Err() == nil {
+				t.Errorf("error is expected")
+			}
+		})
+	}
+}
+
+func TestChangeScanner(t *testing.T) {
+	data := `<osmChange version="0.6">
+		<create><node id="1" version="1" lat="1" lon="2"/></create>
+		<modify><way id="2" version="2"><nd ref="1"/></way></modify>
+		<delete><relation id="3" version="3"/></delete>
+	</osmChange>`
+
+	s := NewChangeScanner(strings.NewReader(data))
+	var actions []string
+	var ids []int64
+	for s.Scan() {
+		actions = append(actions, s.Action())
+		ids = append(ids, s.Object().ObjectID())
+		if s.Action() == ActionDelete && s.Object().(*Relation).Visible {
+			t.Errorf("deleted element must be invisible")
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+
+	if !reflect.DeepEqual(actions, []string{ActionCreate, ActionModify, ActionDelete}) {
+		t.Errorf("incorrect actions: %v", actions)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("incorrect ids: %v", ids)
+	}
+}
+
+func TestPBFRoundTrip(t *testing.T) {
+	deleted := testOSM()
+	deleted.Nodes[1].Visible = false
+	deleted.Nodes[1].Lat, deleted.Nodes[1].Lon = nil, nil
+
+	many := New()
+	for i := 0; i < pbfBlockSize+10; i++ {
+		lat, lon := float64(i)/1e7, -float64(i)/1e7
+		many.Nodes = append(many.Nodes, &Node{ID: int64(i + 1), Lat: &lat, Lon: &lon, Visible: true, Version: 1,
+			Timestamp: deleted.Nodes[0].Timestamp})
+	}
+
+	cases := []struct {
+		name string
+		osm  *OSM
+	}{
+		{name: "empty", osm: New()},
+		{name: "elements", osm: testOSM()},
+		{name: "deleted", osm: deleted},
+		{name: "several blocks", osm: many},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewPBFWriter(buf, false)
+			if err := tc.osm.Stream(w); err != nil {
+				t.Fatalf("stream error: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close error: %v", err)
+			}
+
+			o := New()
+			if err := Copy(o, NewPBFScanner(buf)); err != nil {
+				t.Fatalf("scan error: %v", err)
+			}
+
+			if !reflect.DeepEqual(o.Objects(), tc.osm.Objects()) {
+				t.Errorf("incorrect elements")
+			}
+		})
+	}
+}
+
+func TestPBFScannerErrors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewPBFWriter(buf, false)
+	if err := testOSM().Stream(w); err != nil {
+		t.Fatalf("stream error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+	data := buf.Bytes()
+
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "truncated", data: data[:len(data)-10]},
+		{name: "garbage", data: []byte{0, 0, 0, 3, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewPBFScanner(bytes.NewReader(tc.data))
+			for s.Scan() {
+			}
+			if s.Err() == nil {
+				t.Errorf("error is expected")
+			}
+		})
+	}
+}
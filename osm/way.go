@@ -13,6 +13,11 @@ func (x xmlNameJSONTypeWay) MarshalJSON() ([]byte, error) {
 	return []byte(`"way"`), nil
 }
 
+// UnmarshalJSON ignores the type, it's known from the struct type
+func (x *xmlNameJSONTypeWay) UnmarshalJSON(b []byte) error {
+	return nil
+}
+
 // Way is an osm way and allows for marshalling to/from osm xml.
 type Way struct {
 	XMLName     xmlNameJSONTypeWay `xml:"way" json:"type"`
@@ -40,6 +45,10 @@ type wayNode struct {
 	ID int64 `xml:"ref,attr"`
 }
 
+func (wn wayNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wn.ID)
+}
+
 func (wn *wayNode) UnmarshalJSON(b []byte) error {
 	return json.Unmarshal(b, &wn.ID)
 }
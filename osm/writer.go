@@ -0,0 +1,180 @@
+package osm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Writer is implemented by encoders which write osm elements one by one,
+// so the whole response never has to be kept in memory.
+// Elements are expected in the osm order: nodes, ways, relations, changesets.
+type Writer interface {
+	WriteNode(n *Node) error
+	WriteWay(w *Way) error
+	WriteRelation(r *Relation) error
+	WriteChangeset(c *Changeset) error
+	Close() error
+}
+
+// BoundsWriter is implemented by writers which output bounds of the requested area.
+// WriteBounds has to be called before the first element.
+type BoundsWriter interface {
+	WriteBounds(b *Bounds) error
+}
+
+// WriteBounds sets bounds of the osm object
+func (o *OSM) WriteBounds(b *Bounds) error {
+	o.Bounds = b
+	return nil
+}
+
+// WriteNode appends node to the osm object
+func (o *OSM) WriteNode(n *Node) error {
+	o.Nodes = append(o.Nodes, n)
+	return nil
+}
+
+// WriteWay appends way to the osm object
+func (o *OSM) WriteWay(w *Way) error {
+	o.Ways = append(o.Ways, w)
+	return nil
+}
+
+// WriteRelation appends relation to the osm object
+func (o *OSM) WriteRelation(r *Relation) error {
+	o.Relations = append(o.Relations, r)
+	return nil
+}
+
+// WriteChangeset appends changeset to the osm object
+func (o *OSM) WriteChangeset(c *Changeset) error {
+	o.Changesets = append(o.Changesets, c)
+	return nil
+}
+
+// Close does nothing, the osm object keeps all written elements
+func (o *OSM) Close() error {
+	return nil
+}
+
+// Stream writes all elements of the osm object to w
+func (o *OSM) Stream(w Writer) error {
+	if bw, ok := w.(BoundsWriter); ok && o.Bounds != nil {
+		if err := bw.WriteBounds(o.Bounds); err != nil {
+			return err
+		}
+	}
+	for i := range o.Nodes {
+		if err := w.WriteNode(o.Nodes[i]); err != nil {
+			return err
+		}
+	}
+	for i := range o.Ways {
+		if err := w.WriteWay(o.Ways[i]); err != nil {
+			return err
+		}
+	}
+	for i := range o.Relations {
+		if err := w.WriteRelation(o.Relations[i]); err != nil {
+			return err
+		}
+	}
+	for i := range o.Changesets {
+		if err := w.WriteChangeset(o.Changesets[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONWriter writes osm json incrementally. The output is the same
+// as the output of json encoding of the whole OSM object.
+type JSONWriter struct {
+	w       io.Writer
+	header  *OSM
+	started bool
+	count   int
+}
+
+// NewJSONWriter returns new JSONWriter with the default osm header
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{
+		w:      w,
+		header: New(),
+	}
+}
+
+func (j *JSONWriter) start() error {
+	if j.started {
+		return nil
+	}
+	j.started = true
+
+	header, err := json.Marshal(OSM{
+		Version:     j.header.Version,
+		Generator:   j.header.Generator,
+		Copyright:   j.header.Copyright,
+		Attribution: j.header.Attribution,
+		License:     j.header.License,
+		Bounds:      j.header.Bounds,
+	})
+	if err != nil {
+		return err
+	}
+	// header is encoded with empty elements, drop them to write elements one by one
+	header = bytes.TrimSuffix(header, []byte(`"elements":[]}`))
+	_, err = j.w.Write(append(header, []byte(`"elements":[`)...))
+	return err
+}
+
+func (j *JSONWriter) write(v interface{}) error {
+	if err := j.start(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if j.count > 0 {
+		b = append([]byte{','}, b...)
+	}
+	j.count++
+	_, err = j.w.Write(b)
+	return err
+}
+
+// WriteBounds sets bounds written in the header
+func (j *JSONWriter) WriteBounds(b *Bounds) error {
+	j.header.Bounds = b
+	return nil
+}
+
+// WriteNode writes node
+func (j *JSONWriter) WriteNode(n *Node) error {
+	return j.write(n)
+}
+
+// WriteWay writes way
+func (j *JSONWriter) WriteWay(w *Way) error {
+	return j.write(w)
+}
+
+// WriteRelation writes relation
+func (j *JSONWriter) WriteRelation(r *Relation) error {
+	return j.write(r)
+}
+
+// WriteChangeset writes changeset
+func (j *JSONWriter) WriteChangeset(c *Changeset) error {
+	return j.write(c)
+}
+
+// Close writes the end of the osm document
+func (j *JSONWriter) Close() error {
+	if err := j.start(); err != nil {
+		return err
+	}
+	_, err := j.w.Write([]byte(`]}`))
+	return err
+}
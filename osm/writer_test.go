@@ -0,0 +1,134 @@
+package osm
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testOSM() *OSM {
+	lat, lon := 51.5, -0.1
+	user, uid := "mapper", int64(7)
+	ts := Time(time.Date(2012, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	o := New()
+	o.Nodes = Nodes{
+		{ID: 1, Lat: &lat, Lon: &lon, User: &user, UserID: &uid, Visible: true, Version: 2, ChangesetID: 10, Timestamp: ts,
+			Tags: Tags{{K: "amenity", V: "cafe"}}},
+		{ID: 2, Lat: &lat, Lon: &lon, Visible: true, Version: 1, ChangesetID: 10, Timestamp: ts},
+	}
+	o.Ways = Ways{
+		{ID: 3, Visible: true, Version: 1, ChangesetID: 11, Timestamp: ts, Nodes: wayNodes{{ID: 1}, {ID: 2}},
+			Tags: Tags{{K: "highway", V: "residential"}}},
+	}
+	o.Relations = Relations{
+		{ID: 4, Visible: true, Version: 3, ChangesetID: 12, Timestamp: ts,
+			Members: Members{{Type: "way", Ref: 3, Role: "outer"}, {Type: "node", Ref: 1, Role: ""}}},
+	}
+	return o
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestXMLWriter(t *testing.T) {
+	mapOSM := testOSM()
+	mapOSM.Bounds = &Bounds{MinLat: 51.4, MinLon: -0.2, MaxLat: 51.6, MaxLon: 0}
+	mapOSM.Nodes[0].Tags = append(mapOSM.Nodes[0].Tags, &Tag{K: "name", V: "Tom & \"Jerry's\" <café>\n"})
+	mapOSM.Nodes[1].Visible = false
+	mapOSM.Ways[0].Tags = nil
+
+	changesets := New()
+	user, uid := "mapper", int64(7)
+	changesets.Changesets = Changesets{
+		{ID: 10, User: &user, UserID: &uid, ChangesCount: 3, CommentsCount: 1,
+			MinLat: 51.5, MinLon: -0.1, MaxLat: 51.5, MaxLon: -0.1,
+			Tags: Tags{{K: "comment", V: "cafe"}},
+			Discussion: &ChangesetDiscussion{Comments: []*ChangesetComment{
+				{User: user, UserID: uid, Text: "thanks <3"},
+			}}},
+		{ID: 11, Open: true},
+	}
+
+	cases := []struct {
+		name string
+		osm  *OSM
+	}{
+		{name: "empty", osm: New()},
+		{name: "elements", osm: testOSM()},
+		{name: "map", osm: mapOSM},
+		{name: "changesets", osm: changesets},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewXMLWriter(buf)
+			if err := tc.osm.Stream(w); err != nil {
+				t.Fatalf("stream error: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close error: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tc.name+".xml")
+			if *update {
+				if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatalf("update error: %v", err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("golden file error: %v", err)
+			}
+			if v := buf.String(); v != string(expected) {
+				t.Errorf("incorrect output:\n%v\nexpected:\n%v", v, string(expected))
+			}
+
+			// the output is still valid osm xml
+			decoded := &OSM{}
+			if err := xml.Unmarshal(buf.Bytes(), decoded); err != nil {
+				t.Errorf("unmarshal error: %v", err)
+			}
+			if len(decoded.Objects()) != len(tc.osm.Objects()) {
+				t.Errorf("incorrect number of decoded elements: %v", len(decoded.Objects()))
+			}
+		})
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	cases := []struct {
+		name string
+		osm  *OSM
+	}{
+		{name: "empty", osm: New()},
+		{name: "elements", osm: testOSM()},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, err := json.Marshal(tc.osm)
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+
+			buf := &bytes.Buffer{}
+			w := NewJSONWriter(buf)
+			if err := tc.osm.Stream(w); err != nil {
+				t.Fatalf("stream error: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close error: %v", err)
+			}
+
+			if v := buf.String(); v != string(expected) {
+				t.Errorf("incorrect output:\n%v\nexpected:\n%v", v, string(expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package osm
+
+import (
+	"io"
+	"strconv"
+)
+
+// xmlHeader is the declaration written by cgimap
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+// XMLWriter writes osm xml incrementally. The output is byte-for-byte
+// the same as the output of cgimap: attributes in cgimap order,
+// coordinates with 7 decimals, one space indentation and
+// no user and uid of users whose edits aren't public.
+type XMLWriter struct {
+	w       io.Writer
+	root    string
+	header  *OSM
+	started bool
+	empty   bool
+	buf     []byte
+	// indent is the extra indentation of elements inside action blocks of osmChange
+	indent []byte
+}
+
+// NewXMLWriter returns new XMLWriter with the default osm header
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{
+		w:      w,
+		root:   "osm",
+		header: New(),
+		empty:  true,
+	}
+}
+
+// WriteBounds sets bounds written after the osm element
+func (x *XMLWriter) WriteBounds(b *Bounds) error {
+	x.header.Bounds = b
+	return nil
+}
+
+func (x *XMLWriter) start() error {
+	if x.started {
+		return nil
+	}
+	x.started = true
+
+	b := append(x.buf[:0], xmlHeader...)
+	b = append(b, '<')
+	b = append(b, x.root...)
+	if x.header.Version != 0 {
+		b = appendXMLAttr(b, "version", strconv.FormatFloat(x.header.Version, 'g', -1, 64))
+	}
+	for _, a := range []struct{ name, value string }{
+		{"generator", x.header.Generator},
+		{"copyright", x.header.Copyright},
+		{"attribution", x.header.Attribution},
+		{"license", x.header.License},
+	} {
+		if len(a.value) != 0 {
+			b = appendXMLAttr(b, a.name, a.value)
+		}
+	}
+	x.buf = b
+
+	if bounds := x.header.Bounds; bounds != nil {
+		b = x.openOSM()
+		b = append(b, " <bounds"...)
+		b = appendXMLCoordinate(b, "minlat", bounds.MinLat)
+		b = appendXMLCoordinate(b, "minlon", bounds.MinLon)
+		b = appendXMLCoordinate(b, "maxlat", bounds.MaxLat)
+		b = appendXMLCoordinate(b, "maxlon", bounds.MaxLon)
+		b = append(b, "/>\n"...)
+		x.buf = b
+	}
+	return nil
+}
+
+// openOSM closes the start tag of the root element before its first child
+func (x *XMLWriter) openOSM() []byte {
+	if !x.empty {
+		return x.buf
+	}
+	x.empty = false
+	return append(x.buf, ">\n"...)
+}
+
+func (x *XMLWriter) flush() error {
+	_, err := x.w.Write(x.buf)
+	x.buf = x.buf[:0]
+	return err
+}
+
+func (x *XMLWriter) beginElement(name string, id int64, visible bool, version int,
+	changeset int64, timestamp Time, user *string, userID *int64) []byte {
+	b := append(append(x.openOSM(), x.indent...), ' ', '<')
+	b = append(b, name...)
+	b = appendXMLAttr(b, "id", strconv.FormatInt(id, 10))
+	b = appendXMLAttr(b, "visible", strconv.FormatBool(visible))
+	b = appendXMLAttr(b, "version", strconv.Itoa(version))
+	b = appendXMLAttr(b, "changeset", strconv.FormatInt(changeset, 10))
+	b = appendXMLAttr(b, "timestamp", timestamp.String())
+	return appendXMLUser(b, user, userID)
+}
+
+// endElement closes the element, it's self-closing if it has no children
+func (x *XMLWriter) endElement(b []byte, name string, children []byte) []byte {
+	if len(children) == 0 {
+		return append(b, "/>\n"...)
+	}
+	b = append(b, ">\n"...)
+	b = append(b, children...)
+	b = append(b, x.indent...)
+	b = append(b, " </"...)
+	b = append(b, name...)
+	return append(b, ">\n"...)
+}
+
+// WriteNode writes node
+func (x *XMLWriter) WriteNode(n *Node) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	b := x.beginElement("node", n.ID, n.Visible, n.Version, n.ChangesetID, n.Timestamp, n.User, n.UserID)
+	if n.Visible && n.Lat != nil && n.Lon != nil {
+		b = appendXMLCoordinate(b, "lat", *n.Lat)
+		b = appendXMLCoordinate(b, "lon", *n.Lon)
+	}
+	x.buf = x.endElement(b, "node", x.appendTags(nil, n.Tags))
+	return x.flush()
+}
+
+// WriteWay writes way
+func (x *XMLWriter) WriteWay(w *Way) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	b := x.beginElement("way", w.ID, w.Visible, w.Version, w.ChangesetID, w.Timestamp, w.User, w.UserID)
+	var children []byte
+	for _, n := range w.Nodes {
+		children = append(children, x.indent...)
+		children = append(children, `  <nd ref="`...)
+		children = strconv.AppendInt(children, n.ID, 10)
+		children = append(children, "\"/>\n"...)
+	}
+	x.buf = x.endElement(b, "way", x.appendTags(children, w.Tags))
+	return x.flush()
+}
+
+// WriteRelation writes relation
+func (x *XMLWriter) WriteRelation(r *Relation) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	b := x.beginElement("relation", r.ID, r.Visible, r.Version, r.ChangesetID, r.Timestamp, r.User, r.UserID)
+	var children []byte
+	for _, m := range r.Members {
+		children = append(children, x.indent...)
+		children = append(children, "  <member"...)
+		children = appendXMLAttr(children, "type", m.Type)
+		children = appendXMLAttr(children, "ref", strconv.FormatInt(m.Ref, 10))
+		children = appendXMLAttr(children, "role", m.Role)
+		children = append(children, "/>\n"...)
+	}
+	x.buf = x.endElement(b, "relation", x.appendTags(children, r.Tags))
+	return x.flush()
+}
+
+// WriteChangeset writes changeset
+func (x *XMLWriter) WriteChangeset(c *Changeset) error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	b := append(append(x.openOSM(), x.indent...), " <changeset"...)
+	b = appendXMLAttr(b, "id", strconv.FormatInt(c.ID, 10))
+	b = appendXMLAttr(b, "created_at", c.CreatedAt.String())
+	if !c.Open {
+		b = appendXMLAttr(b, "closed_at", c.ClosedAt.String())
+	}
+	b = appendXMLAttr(b, "open", strconv.FormatBool(c.Open))
+	b = appendXMLUser(b, c.User, c.UserID)
+	if c.MinLat != 0 || c.MinLon != 0 || c.MaxLat != 0 || c.MaxLon != 0 {
+		b = appendXMLCoordinate(b, "min_lat", c.MinLat)
+		b = appendXMLCoordinate(b, "min_lon", c.MinLon)
+		b = appendXMLCoordinate(b, "max_lat", c.MaxLat)
+		b = appendXMLCoordinate(b, "max_lon", c.MaxLon)
+	}
+	b = appendXMLAttr(b, "comments_count", strconv.Itoa(c.CommentsCount))
+	b = appendXMLAttr(b, "changes_count", strconv.Itoa(c.ChangesCount))
+
+	children := x.appendTags(nil, c.Tags)
+	if c.Discussion != nil {
+		children = append(children, x.indent...)
+		children = append(children, "  <discussion"...)
+		var comments []byte
+		for _, cc := range c.Discussion.Comments {
+			comments = append(comments, x.indent...)
+			comments = append(comments, "   <comment"...)
+			comments = appendXMLAttr(comments, "date", cc.Timestamp.String())
+			comments = appendXMLAttr(comments, "uid", strconv.FormatInt(cc.UserID, 10))
+			comments = appendXMLAttr(comments, "user", cc.User)
+			comments = append(comments, ">\n"...)
+			comments = append(comments, x.indent...)
+			comments = append(comments, "    <text>"...)
+			comments = appendXMLEscaped(comments, cc.Text, false)
+			comments = append(comments, "</text>\n"...)
+			comments = append(comments, x.indent...)
+			comments = append(comments, "   </comment>\n"...)
+		}
+		if len(comments) == 0 {
+			children = append(children, "/>\n"...)
+		} else {
+			children = append(children, ">\n"...)
+			children = append(children, comments...)
+			children = append(children, x.indent...)
+			children = append(children, "  </discussion>\n"...)
+		}
+	}
+	x.buf = x.endElement(b, "changeset", children)
+	return x.flush()
+}
+
+// Close writes the end of the osm document
+func (x *XMLWriter) Close() error {
+	if err := x.start(); err != nil {
+		return err
+	}
+	if x.empty {
+		x.buf = append(x.buf, "/>\n"...)
+	} else {
+		x.buf = append(x.buf, "</"...)
+		x.buf = append(x.buf, x.root...)
+		x.buf = append(x.buf, ">\n"...)
+	}
+	return x.flush()
+}
+
+func appendXMLUser(b []byte, user *string, userID *int64) []byte {
+	if user == nil || userID == nil {
+		return b
+	}
+	b = appendXMLAttr(b, "user", *user)
+	return appendXMLAttr(b, "uid", strconv.FormatInt(*userID, 10))
+}
+
+func (x *XMLWriter) appendTags(b []byte, tags Tags) []byte {
+	for _, t := range tags {
+		b = append(b, x.indent...)
+		b = append(b, "  <tag"...)
+		b = appendXMLAttr(b, "k", t.K)
+		b = appendXMLAttr(b, "v", t.V)
+		b = append(b, "/>\n"...)
+	}
+	return b
+}
+
+func appendXMLCoordinate(b []byte, name string, v float64) []byte {
+	return appendXMLAttr(b, name, strconv.FormatFloat(v, 'f', 7, 64))
+}
+
+func appendXMLAttr(b []byte, name, value string) []byte {
+	b = append(b, ' ')
+	b = append(b, name...)
+	b = append(b, '=', '"')
+	b = appendXMLEscaped(b, value, true)
+	return append(b, '"')
+}
+
+// appendXMLEscaped escapes the text the same way as libxml2 used by cgimap
+func appendXMLEscaped(b []byte, s string, attr bool) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '&':
+			b = append(b, "&amp;"...)
+		case c == '<':
+			b = append(b, "&lt;"...)
+		case c == '>':
+			b = append(b, "&gt;"...)
+		case c == '\r':
+			b = append(b, "&#13;"...)
+		case attr && c == '"':
+			b = append(b, "&quot;"...)
+		case attr && c == '\n':
+			b = append(b, "&#10;"...)
+		case attr && c == '\t':
+			b = append(b, "&#9;"...)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
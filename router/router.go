@@ -10,6 +10,10 @@ import (
 // Load returns api router
 func Load(config *config.Config, s *server.Server) *echo.Echo {
 	e := echo.New()
+	e.HTTPErrorHandler = s.HandleError
+	e.Pre(s.CheckURILength)
+	e.Pre(s.FormatExtension)
+	e.Pre(s.RewriteXAPI)
 	e.Use(middleware.Logger())
 	e.Use(middleware.GzipWithConfig(middleware.GzipConfig{}))
 
@@ -28,12 +32,22 @@ func Load(config *config.Config, s *server.Server) *echo.Echo {
 	node06.GET("/:id/:version", s.GetNodeByVersion)
 	node06.HEAD("/:id/history", s.GetNodeHistory)
 	node06.GET("/:id/history", s.GetNodeHistory)
+	node06.HEAD("/:id/relations", s.GetNodeRelations)
+	node06.GET("/:id/relations", s.GetNodeRelations)
+	node06.HEAD("/:id/diff", s.GetNodeDiff)
+	node06.GET("/:id/diff", s.GetNodeDiff)
+	node06.HEAD("/:id/blame", s.GetNodeBlame)
+	node06.GET("/:id/blame", s.GetNodeBlame)
+	node06.HEAD("/:id/ancestors", s.GetNodeAncestors)
+	node06.GET("/:id/ancestors", s.GetNodeAncestors)
 	node06.HEAD("/:id/ways", s.GetWaysByNode)
 	node06.GET("/:id/ways", s.GetWaysByNode)
 
 	nodes06 := api06.Group("/nodes")
 	nodes06.HEAD("", s.GetNodes)
 	nodes06.GET("", s.GetNodes)
+	nodes06.HEAD("/history", s.GetNodesHistory)
+	nodes06.GET("/history", s.GetNodesHistory)
 
 	way06 := api06.Group("/way")
 	way06.HEAD("/:id", s.GetWay)
@@ -42,12 +56,24 @@ func Load(config *config.Config, s *server.Server) *echo.Echo {
 	way06.GET("/:id/:version", s.GetWayByVersion)
 	way06.HEAD("/:id/full", s.GetWayFull)
 	way06.GET("/:id/full", s.GetWayFull)
+	way06.HEAD("/:id/:version/full", s.GetWayVersionFull)
+	way06.GET("/:id/:version/full", s.GetWayVersionFull)
 	way06.HEAD("/:id/history", s.GetWayHistory)
 	way06.GET("/:id/history", s.GetWayHistory)
+	way06.HEAD("/:id/relations", s.GetWayRelations)
+	way06.GET("/:id/relations", s.GetWayRelations)
+	way06.HEAD("/:id/diff", s.GetWayDiff)
+	way06.GET("/:id/diff", s.GetWayDiff)
+	way06.HEAD("/:id/blame", s.GetWayBlame)
+	way06.GET("/:id/blame", s.GetWayBlame)
+	way06.HEAD("/:id/ancestors", s.GetWayAncestors)
+	way06.GET("/:id/ancestors", s.GetWayAncestors)
 
 	ways06 := api06.Group("/ways")
 	ways06.HEAD("", s.GetWays)
 	ways06.GET("", s.GetWays)
+	ways06.HEAD("/history", s.GetWaysHistory)
+	ways06.GET("/history", s.GetWaysHistory)
 
 	relation06 := api06.Group("/relation")
 	relation06.HEAD("/:id", s.GetRelation)
@@ -56,16 +82,43 @@ func Load(config *config.Config, s *server.Server) *echo.Echo {
 	relation06.GET("/:id/:version", s.GetRelationByVersion)
 	relation06.HEAD("/:id/full", s.GetRelationFull)
 	relation06.GET("/:id/full", s.GetRelationFull)
+	relation06.HEAD("/:id/:version/full", s.GetRelationVersionFull)
+	relation06.GET("/:id/:version/full", s.GetRelationVersionFull)
 	relation06.HEAD("/:id/history", s.GetRelationHistory)
 	relation06.GET("/:id/history", s.GetRelationHistory)
+	relation06.HEAD("/:id/relations", s.GetRelationRelations)
+	relation06.GET("/:id/relations", s.GetRelationRelations)
+	relation06.HEAD("/:id/diff", s.GetRelationDiff)
+	relation06.GET("/:id/diff", s.GetRelationDiff)
+	relation06.HEAD("/:id/blame", s.GetRelationBlame)
+	relation06.GET("/:id/blame", s.GetRelationBlame)
+	relation06.HEAD("/:id/ancestors", s.GetRelationAncestors)
+	relation06.GET("/:id/ancestors", s.GetRelationAncestors)
+	relation06.HEAD("/:id/graph", s.GetRelationGraph)
+	relation06.GET("/:id/graph", s.GetRelationGraph)
 
 	relations06 := api06.Group("/relations")
 	relations06.HEAD("", s.GetRelations)
 	relations06.GET("", s.GetRelations)
+	relations06.HEAD("/history", s.GetRelationsHistory)
+	relations06.GET("/history", s.GetRelationsHistory)
+
+	elements06 := api06.Group("/elements")
+	elements06.HEAD("", s.GetElements)
+	elements06.GET("", s.GetElements)
+	elements06.POST("", s.GetElements)
+
+	xapi06 := api06.Group("/xapi")
+	xapi06.HEAD("", s.GetXAPI)
+	xapi06.GET("", s.GetXAPI)
 
 	changeset06 := api06.Group("/changeset")
 	changeset06.HEAD("/:id", s.GetChangeset)
 	changeset06.GET("/:id", s.GetChangeset)
 
+	tiles := e.Group("/tiles")
+	tiles.HEAD("/:z/:x/:y", s.GetTile)
+	tiles.GET("/:z/:x/:y", s.GetTile)
+
 	return e
 }
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"github.com/labstack/echo"
+)
+
+// GetNodeBlame returns versions which set the current tags of the node
+func (s *Server) GetNodeBlame(c echo.Context) error {
+	return s.getBlame(c, "node")
+}
+
+// GetWayBlame returns versions which set the current tags and nodes of the way
+func (s *Server) GetWayBlame(c echo.Context) error {
+	return s.getBlame(c, "way")
+}
+
+// GetRelationBlame returns versions which set the current tags and members of the relation
+func (s *Server) GetRelationBlame(c echo.Context) error {
+	return s.getBlame(c, "relation")
+}
+
+func (s *Server) getBlame(c echo.Context, elementType string) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.g.BlameHandler(elementType, id)
+	if err != nil {
+		return err
+	}
+
+	return s.encodeDocument(c, resp)
+}
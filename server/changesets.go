@@ -1,8 +1,6 @@
 package server
 
 import (
-	"encoding/xml"
-	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo"
@@ -11,9 +9,8 @@ import (
 
 // GetChangeset returns changeset by id
 func (s *Server) GetChangeset(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
@@ -22,21 +19,14 @@ func (s *Server) GetChangeset(c echo.Context) error {
 	if len(includeDiscussionRaw) != 0 {
 		includeDiscussion, err = strconv.ParseBool(includeDiscussionRaw)
 		if err != nil {
-			s.SetEmptyResultHeaders(c, http.StatusNotFound)
-			return err
+			return gomap.BadRequest("The parameter include_discussion must be a boolean")
 		}
 	}
 
 	resp, err := s.g.ChangesetHandler(id, includeDiscussion)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
-		return err
-	}
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
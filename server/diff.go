@@ -0,0 +1,42 @@
+package server
+
+import (
+	"github.com/labstack/echo"
+)
+
+// GetNodeDiff returns the difference between two versions of the node
+func (s *Server) GetNodeDiff(c echo.Context) error {
+	return s.getDiff(c, "node")
+}
+
+// GetWayDiff returns the difference between two versions of the way
+func (s *Server) GetWayDiff(c echo.Context) error {
+	return s.getDiff(c, "way")
+}
+
+// GetRelationDiff returns the difference between two versions of the relation
+func (s *Server) GetRelationDiff(c echo.Context) error {
+	return s.getDiff(c, "relation")
+}
+
+func (s *Server) getDiff(c echo.Context, elementType string) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	from, err := parseOptionalVersion(c.QueryParam("from"))
+	if err != nil {
+		return err
+	}
+	to, err := parseOptionalVersion(c.QueryParam("to"))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.g.DiffHandler(elementType, id, from, to)
+	if err != nil {
+		return err
+	}
+
+	return s.encodeDocument(c, resp)
+}
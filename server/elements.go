@@ -0,0 +1,45 @@
+package server
+
+import (
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+)
+
+// GetElements returns nodes, ways and relations by ids
+func (s *Server) GetElements(c echo.Context) error {
+	ids, err := parseElementIDs(c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.g.ElementsHandler(ids[0], ids[1], ids[2])
+	if err != nil {
+		return err
+	}
+
+	return s.encode(c, resp)
+}
+
+// parseElementIDs parses nodes, ways and relations parameters of elements request.
+// The parameters are read from the query or from the form-encoded body of POST request,
+// the body takes precedence if a parameter is in both. The limit of ids applies to each type.
+func parseElementIDs(c echo.Context) ([3]gomap.ElementIDs, error) {
+	var ids [3]gomap.ElementIDs
+	var found bool
+	for i, param := range []string{"nodes", "ways", "relations"} {
+		raw := c.FormValue(param)
+		if len(raw) == 0 {
+			continue
+		}
+		found = true
+		var err error
+		if ids[i].IDs, ids[i].HistIDs, err = parseMultiFetchIDs(raw, param); err != nil {
+			return ids, err
+		}
+	}
+	if !found {
+		return ids, gomap.BadRequest("At least one of the parameters nodes, ways and relations is required, " +
+			"and must be of the form nodes=id[,id[,id...]]")
+	}
+	return ids, nil
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+)
+
+func TestParseElementIDs(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+		ids    [3]gomap.ElementIDs
+		ok     bool
+	}{
+		{
+			name:   "query",
+			method: http.MethodGet,
+			query:  "nodes=1,2v3&relations=4",
+			ids: [3]gomap.ElementIDs{
+				{IDs: []int64{1}, HistIDs: [][2]int64{{2, 3}}},
+				{},
+				{IDs: []int64{4}, HistIDs: [][2]int64{}},
+			},
+			ok: true,
+		},
+		{
+			name:   "body",
+			method: http.MethodPost,
+			body:   "ways=5,6",
+			ids:    [3]gomap.ElementIDs{{}, {IDs: []int64{5, 6}, HistIDs: [][2]int64{}}, {}},
+			ok:     true,
+		},
+		{
+			name:   "query and body",
+			method: http.MethodPost,
+			query:  "nodes=1",
+			body:   "ways=5",
+			ids: [3]gomap.ElementIDs{
+				{IDs: []int64{1}, HistIDs: [][2]int64{}},
+				{IDs: []int64{5}, HistIDs: [][2]int64{}},
+				{},
+			},
+			ok: true,
+		},
+		{
+			name:   "body takes precedence",
+			method: http.MethodPost,
+			query:  "nodes=1",
+			body:   "nodes=2",
+			ids:    [3]gomap.ElementIDs{{IDs: []int64{2}, HistIDs: [][2]int64{}}, {}, {}},
+			ok:     true,
+		},
+		{name: "no parameter", method: http.MethodGet, query: "changesets=1"},
+		{name: "empty body", method: http.MethodPost},
+		{name: "invalid id", method: http.MethodPost, query: "nodes=1", body: "ways=a"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/0.6/elements?"+tc.query, strings.NewReader(tc.body))
+			if tc.method == http.MethodPost {
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			ids, err := parseElementIDs(c)
+			if !tc.ok {
+				if e, ok := err.(*gomap.Error); !ok || e.Status != http.StatusBadRequest {
+					t.Errorf("incorrect error %v, expected bad request", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(ids, tc.ids) {
+				t.Errorf("incorrect ids: %+v, expected %+v", ids, tc.ids)
+			}
+		})
+	}
+}
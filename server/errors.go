@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+)
+
+// maxURILength is the longest request uri which is accepted
+const maxURILength = 8192
+
+var errURITooLong = gomap.NewError(http.StatusRequestURITooLong, "The request URI is too long")
+
+// CheckURILength is middleware which rejects too long request uris
+func (s *Server) CheckURILength(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if len(c.Request().RequestURI) > maxURILength {
+			return errURITooLong
+		}
+		return next(c)
+	}
+}
+
+// HandleError sends the error to the client the same way as osm.org does:
+// plain text message in the body and in the Error header.
+// Messages of unexpected errors aren't sent, they are only logged.
+// Errors after the first chunk of a streamed response abort the connection.
+func (s *Server) HandleError(err error, c echo.Context) {
+	res := c.Response()
+	if res.Committed {
+		// the response is already being streamed, it's too late to change status,
+		// the connection is aborted so the client doesn't take the truncated body as complete
+		c.Logger().Error(err)
+		panic(http.ErrAbortHandler)
+	}
+
+	status, message := http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	switch e := err.(type) {
+	case *gomap.Error:
+		status, message = e.Status, e.Message
+	case *echo.HTTPError:
+		status, message = e.Code, http.StatusText(e.Code)
+		if m, ok := e.Message.(string); ok {
+			message = m
+		}
+	default:
+		c.Logger().Error(err)
+	}
+
+	res.Header().Set(echo.HeaderContentType, strings.ToLower(echo.MIMETextPlainCharsetUTF8))
+	res.Header().Set("Error", message)
+	res.Header().Set("Cache-Control", "no-cache")
+	res.WriteHeader(status)
+	if c.Request().Method != echo.HEAD {
+		res.Write([]byte(message))
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+)
+
+func TestHandleErrorCommitted(t *testing.T) {
+	c, rec := newTestContext("/api/0.6/map?bbox=0,0,1,1")
+	s := &Server{}
+	s.SetHeaders(c, "text/xml; charset=utf-8")
+	c.Response().Write([]byte("<osm>"))
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("connection isn't aborted: %v", r)
+		}
+		if rec.Code != http.StatusOK || rec.Body.String() != "<osm>" {
+			t.Errorf("sent response is changed: %v %q", rec.Code, rec.Body.String())
+		}
+	}()
+	s.HandleError(errors.New("connection reset"), c)
+}
+
+func TestHandleError(t *testing.T) {
+	cases := []struct {
+		name    string
+		method  string
+		err     error
+		status  int
+		message string
+	}{
+		{
+			name:    "typed error",
+			method:  http.MethodGet,
+			err:     gomap.ErrElementDeleted,
+			status:  http.StatusGone,
+			message: gomap.ErrElementDeleted.Message,
+		},
+		{
+			name:    "echo error",
+			method:  http.MethodGet,
+			err:     echo.ErrNotFound,
+			status:  http.StatusNotFound,
+			message: "Not Found",
+		},
+		{
+			name:    "unexpected error",
+			method:  http.MethodGet,
+			err:     errors.New("database is down"),
+			status:  http.StatusInternalServerError,
+			message: "Internal Server Error",
+		},
+		{
+			name:    "head request",
+			method:  http.MethodHead,
+			err:     gomap.ErrElementNotFound,
+			status:  http.StatusNotFound,
+			message: gomap.ErrElementNotFound.Message,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(tc.method, "/api/0.6/node/1", nil), rec)
+			(&Server{}).HandleError(tc.err, c)
+
+			if rec.Code != tc.status {
+				t.Errorf("incorrect status %v, expected %v", rec.Code, tc.status)
+			}
+			if v := rec.Header().Get("Error"); v != tc.message {
+				t.Errorf("incorrect Error header %q, expected %q", v, tc.message)
+			}
+			body := tc.message
+			if tc.method == http.MethodHead {
+				body = ""
+			}
+			if rec.Body.String() != body {
+				t.Errorf("incorrect body %q, expected %q", rec.Body.String(), body)
+			}
+		})
+	}
+}
+
+func TestCheckURILength(t *testing.T) {
+	cases := []struct {
+		name   string
+		length int
+		err    error
+	}{
+		{name: "limit", length: maxURILength},
+		{name: "too long", length: maxURILength + 1, err: errURITooLong},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := "/api/0.6/nodes?nodes=1"
+			target += strings.Repeat("0", tc.length-len(target))
+			c, _ := newTestContext(target)
+
+			called := false
+			err := (&Server{}).CheckURILength(func(echo.Context) error {
+				called = true
+				return nil
+			})(c)
+			if err != tc.err {
+				t.Errorf("incorrect error: %v", err)
+			}
+			if called != (tc.err == nil) {
+				t.Errorf("next handler called: %v", called)
+			}
+		})
+	}
+}
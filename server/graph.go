@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+	"github.com/osmlab/gomap/osm"
+)
+
+const (
+	formatDOT = "dot"
+	mimeDOT   = "text/vnd.graphviz"
+)
+
+// GetRelationGraph returns membership graph of the relation
+func (s *Server) GetRelationGraph(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.g.RelationGraphHandler(id)
+	if err != nil {
+		return err
+	}
+
+	return s.encodeGraph(c, resp)
+}
+
+// GetNodeAncestors returns relations containing the node directly or through other relations
+func (s *Server) GetNodeAncestors(c echo.Context) error {
+	return s.getAncestors(c, "node")
+}
+
+// GetWayAncestors returns relations containing the way directly or through other relations
+func (s *Server) GetWayAncestors(c echo.Context) error {
+	return s.getAncestors(c, "way")
+}
+
+// GetRelationAncestors returns relations containing the relation directly or through other relations
+func (s *Server) GetRelationAncestors(c echo.Context) error {
+	return s.getAncestors(c, "relation")
+}
+
+func (s *Server) getAncestors(c echo.Context, elementType string) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.g.AncestorsHandler(elementType, id)
+	if err != nil {
+		return err
+	}
+
+	return s.encodeGraph(c, resp)
+}
+
+// encodeGraph writes the graph as json by default or in graphviz dot format
+// for ?format=dot or Accept: text/vnd.graphviz
+func (s *Server) encodeGraph(c echo.Context, g *osm.Graph) error {
+	format := c.QueryParam("format")
+	if len(format) == 0 && strings.Contains(c.Request().Header.Get(echo.HeaderAccept), mimeDOT) {
+		format = formatDOT
+	}
+
+	var contentType string
+	var data []byte
+	switch format {
+	case formatDOT:
+		var b bytes.Buffer
+		if err := g.WriteDOT(&b); err != nil {
+			return err
+		}
+		contentType, data = mimeDOT, b.Bytes()
+	case "", formatJSON:
+		var err error
+		if data, err = json.Marshal(g); err != nil {
+			return err
+		}
+		contentType = echo.MIMEApplicationJSONCharsetUTF8
+	default:
+		return gomap.BadRequest("Format %q isn't supported by this call, use json or dot", format)
+	}
+
+	s.SetHeaders(c, contentType)
+	_, err := c.Response().Write(data)
+	return err
+}
@@ -3,14 +3,193 @@ package server
 import (
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
 )
 
+// maxMultiFetchIDs is the largest number of ids of multi fetch requests
+const maxMultiFetchIDs = 1000
+
+// maxHistoryLimit is the largest page size of history requests
+const maxHistoryLimit = 10000
+
+// parseID parses positive element id
+func parseID(raw string) (int64, error) {
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, gomap.BadRequest("Id must be a positive number, got %q", raw)
+	}
+	return id, nil
+}
+
+// parseVersion parses positive element version
+func parseVersion(raw string) (int64, error) {
+	version, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, gomap.BadRequest("Version must be a positive number, got %q", raw)
+	}
+	return version, nil
+}
+
+// getBulkIDs parses ids of bulk request, versions aren't allowed
+func getBulkIDs(c echo.Context, param string) ([]int64, error) {
+	ids, histIDs, err := getMultiFetchIDs(c, param)
+	if err != nil {
+		return nil, err
+	}
+	if len(histIDs) != 0 {
+		return nil, gomap.BadRequest("The parameter %v must be of the form %v=id[,id[,id...]], versions aren't allowed", param, param)
+	}
+	return ids, nil
+}
+
+// parseHistoryRange parses from_version, to_version, since and limit parameters of history requests
+func parseHistoryRange(c echo.Context) (gomap.HistoryRange, error) {
+	var r gomap.HistoryRange
+	var err error
+	if r.FromVersion, err = parseOptionalVersion(c.QueryParam("from_version")); err != nil {
+		return r, err
+	}
+	if r.ToVersion, err = parseOptionalVersion(c.QueryParam("to_version")); err != nil {
+		return r, err
+	}
+	if r.ToVersion != 0 && r.FromVersion > r.ToVersion {
+		return r, gomap.BadRequest("from_version must not be greater than to_version")
+	}
+	if raw := c.QueryParam("since"); len(raw) != 0 {
+		if r.Since, err = parseDate(raw); err != nil {
+			return r, err
+		}
+	}
+	if raw := c.QueryParam("limit"); len(raw) != 0 {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxHistoryLimit {
+			return r, gomap.BadRequest("Limit must be between 1 and %v", maxHistoryLimit)
+		}
+		r.Limit = limit
+	}
+	return r, nil
+}
+
+// setNextPage adds the link to the next page of history, next is its first version
+func setNextPage(c echo.Context, next int64) {
+	if next == 0 {
+		return
+	}
+	u := *c.Request().URL
+	q := u.Query()
+	q.Set("from_version", strconv.FormatInt(next, 10))
+	u.RawQuery = q.Encode()
+	c.Response().Header().Set("Link", "<"+u.RequestURI()+">; rel=\"next\"")
+}
+
+// parseRecurse parses recurse and depth parameters of relation full request,
+// depth 0 means all levels
+func parseRecurse(c echo.Context) (bool, int, error) {
+	switch c.QueryParam("recurse") {
+	case "":
+		if len(c.QueryParam("depth")) != 0 {
+			return false, 0, gomap.BadRequest("The parameter depth requires recurse=all")
+		}
+		return false, 0, nil
+	case "all":
+	default:
+		return false, 0, gomap.BadRequest("The parameter recurse must be all")
+	}
+
+	raw := c.QueryParam("depth")
+	if len(raw) == 0 {
+		return true, 0, nil
+	}
+	depth, err := strconv.Atoi(raw)
+	if err != nil || depth < 1 {
+		return false, 0, gomap.BadRequest("Depth must be a positive number")
+	}
+	return true, depth, nil
+}
+
+// parseHistoryFlag parses history parameter of reverse lookups
+func parseHistoryFlag(c echo.Context) (bool, error) {
+	switch c.QueryParam("history") {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	}
+	return false, gomap.BadRequest("The parameter history must be true or false")
+}
+
+// parseOptionalVersion parses version parameter, 0 is returned if it's not set
+func parseOptionalVersion(raw string) (int64, error) {
+	if len(raw) == 0 {
+		return 0, nil
+	}
+	return parseVersion(raw)
+}
+
+// getMultiFetchIDs parses current and historic ids of multi fetch request
+func getMultiFetchIDs(c echo.Context, param string) ([]int64, [][2]int64, error) {
+	raw := c.QueryParam(param)
+	if len(raw) == 0 {
+		return nil, nil, gomap.BadRequest(
+			"The parameter %v is required, and must be of the form %v=id[,id[,id...]].", param, param)
+	}
+	return parseMultiFetchIDs(raw, param)
+}
+
+// parseMultiFetchIDs parses comma separated current and historic ids
+func parseMultiFetchIDs(raw, param string) ([]int64, [][2]int64, error) {
+	rawIDs := strings.Split(raw, ",")
+	if len(rawIDs) > maxMultiFetchIDs {
+		return nil, nil, gomap.BadRequest(
+			"You requested too many %v (limit is %v)", param, maxMultiFetchIDs)
+	}
+	return getCurrentHistoricIDs(rawIDs)
+}
+
+// dateFormats are accepted formats of date parameters
+var dateFormats = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"}
+
+// parseDate parses date parameter, dates without time zone are in UTC
+func parseDate(raw string) (time.Time, error) {
+	for _, format := range dateFormats {
+		if date, err := time.Parse(format, raw); err == nil {
+			return date.UTC(), nil
+		}
+	}
+	return time.Time{}, gomap.BadRequest("Date must be of the form YYYY-MM-DDThh:mm:ssZ, got %q", raw)
+}
+
+// headerHistoricalDate marks responses with elements as they were at the date
+const headerHistoricalDate = "X-Historical-Date"
+
+// historicalDate parses the optional date parameter and marks the response
+// as historical if it's set
+func historicalDate(c echo.Context, param string) (time.Time, bool, error) {
+	raw := c.QueryParam(param)
+	if len(raw) == 0 {
+		return time.Time{}, false, nil
+	}
+	date, err := parseDate(raw)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	// the response isn't the current data, clients and caches have to know it
+	c.Response().Header().Set(headerHistoricalDate, date.Format(time.RFC3339))
+	return date, true, nil
+}
+
 func getCurrentHistoricIDs(rawIDs []string) ([]int64, [][2]int64, error) {
 	currentIDs := make([]int64, 0)
 	historicIDs := make([][2]int64, 0)
 	for i := range rawIDs {
 		idv := strings.Split(rawIDs[i], "v")
-		id, err := strconv.ParseInt(idv[0], 10, 64)
+		if len(idv) > 2 {
+			return nil, nil, gomap.BadRequest("Id must be of the form id or idvversion, got %q", rawIDs[i])
+		}
+		id, err := parseID(idv[0])
 		if err != nil {
 			return nil, nil, err
 		}
@@ -18,7 +197,7 @@ func getCurrentHistoricIDs(rawIDs []string) ([]int64, [][2]int64, error) {
 			currentIDs = appendIfUnique(currentIDs, id)
 			continue
 		}
-		v, err := strconv.ParseInt(idv[1], 10, 64)
+		v, err := parseVersion(idv[1])
 		if err != nil {
 			return nil, nil, err
 		}
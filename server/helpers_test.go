@@ -0,0 +1,163 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+)
+
+// newTestContext returns context of GET request of the target
+func newTestContext(target string) (echo.Context, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestGetMultiFetchIDs(t *testing.T) {
+	many := make([]string, maxMultiFetchIDs+1)
+	for i := range many {
+		many[i] = strconv.Itoa(i + 1)
+	}
+
+	cases := []struct {
+		name     string
+		raw      string
+		current  []int64
+		historic [][2]int64
+		ok       bool
+	}{
+		{name: "current", raw: "1,2,3", current: []int64{1, 2, 3}, historic: [][2]int64{}, ok: true},
+		{name: "historic", raw: "1v2,3", current: []int64{3}, historic: [][2]int64{{1, 2}}, ok: true},
+		{name: "duplicates", raw: "1,1v2,1,1v2,1v3", current: []int64{1}, historic: [][2]int64{{1, 2}, {1, 3}}, ok: true},
+		{name: "limit", raw: strings.Join(many[:maxMultiFetchIDs], ","), ok: true},
+		{name: "too many", raw: strings.Join(many, ",")},
+		{name: "empty", raw: ""},
+		{name: "empty id", raw: "1,,2"},
+		{name: "several versions", raw: "1v2v3"},
+		{name: "no version", raw: "1v"},
+		{name: "zero id", raw: "0"},
+		{name: "negative version", raw: "1v-1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := newTestContext("/api/0.6/nodes?nodes=" + tc.raw)
+			current, historic, err := getMultiFetchIDs(c, "nodes")
+			if (err == nil) != tc.ok {
+				t.Fatalf("incorrect error: %v", err)
+			}
+			if !tc.ok || tc.current == nil {
+				return
+			}
+			if !reflect.DeepEqual(current, tc.current) || !reflect.DeepEqual(historic, tc.historic) {
+				t.Errorf("incorrect ids %v %v, expected %v %v", current, historic, tc.current, tc.historic)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	cases := []struct {
+		raw  string
+		date time.Time
+		ok   bool
+	}{
+		{raw: "2017-08-14T10:21:03Z", date: time.Date(2017, 8, 14, 10, 21, 3, 0, time.UTC), ok: true},
+		{raw: "2017-08-14T12:21:03+02:00", date: time.Date(2017, 8, 14, 10, 21, 3, 0, time.UTC), ok: true},
+		{raw: "2017-08-14T10:21", date: time.Date(2017, 8, 14, 10, 21, 0, 0, time.UTC), ok: true},
+		{raw: "2017-08-14", date: time.Date(2017, 8, 14, 0, 0, 0, 0, time.UTC), ok: true},
+		{raw: "2017-08-14 10:21:03"},
+		{raw: "14.08.2017"},
+		{raw: "2017-13-01"},
+		{raw: "now"},
+	}
+
+	for _, tc := range cases {
+		date, err := parseDate(tc.raw)
+		if (err == nil) != tc.ok {
+			t.Errorf("incorrect error of %q: %v", tc.raw, err)
+			continue
+		}
+		if tc.ok && (!date.Equal(tc.date) || date.Location() != time.UTC) {
+			t.Errorf("incorrect date of %q: %v, expected %v", tc.raw, date, tc.date)
+		}
+	}
+}
+
+func TestHistoricalDate(t *testing.T) {
+	c, rec := newTestContext("/api/0.6/map?bbox=0,0,1,1&date=2017-08-14T12:21:03%2B02:00")
+	date, ok, err := historicalDate(c, "date")
+	if err != nil || !ok || !date.Equal(time.Date(2017, 8, 14, 10, 21, 3, 0, time.UTC)) {
+		t.Errorf("incorrect date: %v %v %v", date, ok, err)
+	}
+	if v := rec.Header().Get(headerHistoricalDate); v != "2017-08-14T10:21:03Z" {
+		t.Errorf("incorrect %v header %q", headerHistoricalDate, v)
+	}
+
+	c, rec = newTestContext("/api/0.6/map?bbox=0,0,1,1")
+	if _, ok, err := historicalDate(c, "date"); ok || err != nil {
+		t.Errorf("current request is historical: %v %v", ok, err)
+	}
+	if v := rec.Header().Get(headerHistoricalDate); v != "" {
+		t.Errorf("current response has %v header %q", headerHistoricalDate, v)
+	}
+
+	c, _ = newTestContext("/api/0.6/node/1?at=yesterday")
+	if _, _, err := historicalDate(c, "at"); err == nil {
+		t.Error("invalid date is parsed")
+	}
+}
+
+func TestParseHistoryRange(t *testing.T) {
+	cases := []struct {
+		query string
+		r     gomap.HistoryRange
+		ok    bool
+	}{
+		{query: "", ok: true},
+		{query: "from_version=2&to_version=5&limit=3", r: gomap.HistoryRange{FromVersion: 2, ToVersion: 5, Limit: 3}, ok: true},
+		{query: "from_version=2&to_version=2", r: gomap.HistoryRange{FromVersion: 2, ToVersion: 2}, ok: true},
+		{query: "since=2017-08-14", r: gomap.HistoryRange{Since: time.Date(2017, 8, 14, 0, 0, 0, 0, time.UTC)}, ok: true},
+		{query: "limit=10000", r: gomap.HistoryRange{Limit: 10000}, ok: true},
+		{query: "from_version=3&to_version=2"},
+		{query: "from_version=0"},
+		{query: "to_version=a"},
+		{query: "since=yesterday"},
+		{query: "limit=0"},
+		{query: "limit=10001"},
+	}
+
+	for _, tc := range cases {
+		c, _ := newTestContext("/api/0.6/node/1/history?" + tc.query)
+		r, err := parseHistoryRange(c)
+		if (err == nil) != tc.ok {
+			t.Errorf("incorrect error of %q: %v", tc.query, err)
+			continue
+		}
+		if tc.ok && r != tc.r {
+			t.Errorf("incorrect range of %q: %+v, expected %+v", tc.query, r, tc.r)
+		}
+	}
+}
+
+func TestSetNextPage(t *testing.T) {
+	c, rec := newTestContext("/api/0.6/node/1/history?limit=2&from_version=1")
+	setNextPage(c, 3)
+	expected := `</api/0.6/node/1/history?from_version=3&limit=2>; rel="next"`
+	if link := rec.Header().Get("Link"); link != expected {
+		t.Errorf("incorrect link %q, expected %q", link, expected)
+	}
+
+	c, rec = newTestContext("/api/0.6/node/1/history?limit=2")
+	setNextPage(c, 0)
+	if link := rec.Header().Get("Link"); link != "" {
+		t.Errorf("link of the last page %q", link)
+	}
+}
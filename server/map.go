@@ -1,43 +1,95 @@
 package server
 
 import (
-	"encoding/xml"
-	"errors"
-	"net/http"
-	"strconv"
-	"strings"
-
 	"github.com/labstack/echo"
 	"github.com/osmlab/gomap/gomap"
 )
 
-// GetMap returns amp elements
+// GetMap returns map elements in the bbox, in the polygon of poly parameter
+// or in the outline of the relation of relation parameter. Elements at the past
+// date are returned if date parameter is set.
 func (s *Server) GetMap(c echo.Context) error {
-	bboxRaw := strings.Split(c.QueryParam("bbox"), ",")
-	if len(bboxRaw) != 4 {
-		return errors.New("few arguments")
+	areas := 0
+	for _, param := range []string{"bbox", "poly", "relation"} {
+		if len(c.QueryParam(param)) != 0 {
+			areas++
+		}
+	}
+	if areas > 1 {
+		return gomap.BadRequest("Only one of the parameters bbox, poly and relation can be set")
 	}
 
-	bbox := []int64{}
-	for i := range bboxRaw {
-		bboxRaw[i] += "0000000000"
-		arg, err := strconv.ParseInt(strings.Replace(bboxRaw[i], ".", "", -1)[:8], 10, 64)
+	if raw := c.QueryParam("poly"); len(raw) != 0 {
+		polygons, err := gomap.ParsePolygon(raw)
+		if err != nil {
+			return err
+		}
+		if err := s.checkNoDate(c); err != nil {
+			return err
+		}
+		w, err := s.newWriter(c)
 		if err != nil {
 			return err
 		}
-		bbox = append(bbox, arg)
+		if err := s.g.PolygonMapHandler(polygons, w); err != nil {
+			return err
+		}
+		return w.Close()
 	}
 
-	resp, err := s.g.MapHandler(bbox)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	if raw := c.QueryParam("relation"); len(raw) != 0 {
+		id, err := parseID(raw)
+		if err != nil {
+			return err
+		}
+		if err := s.checkNoDate(c); err != nil {
+			return err
+		}
+		w, err := s.newWriter(c)
+		if err != nil {
+			return err
+		}
+		if err := s.g.RelationMapHandler(id, w); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+
+	bbox, err := gomap.ParseBBox(c.QueryParam("bbox"))
+	if err != nil {
 		return err
 	}
+
+	w, err := s.newWriter(c)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
+		return err
+	}
+	if err := w.WriteBounds(bbox.Bounds()); err != nil {
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	date, historical, err := historicalDate(c, "date")
+	if err != nil {
+		return err
+	}
+	if historical {
+		if err := s.g.HistoricalMapHandler(bbox, date, w); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+
+	if err := s.g.MapHandler(bbox, w); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// checkNoDate rejects date parameter, it's supported only with bbox
+func (s *Server) checkNoDate(c echo.Context) error {
+	if len(c.QueryParam("date")) != 0 {
+		return gomap.BadRequest("The parameter date is supported only with bbox")
+	}
+	return nil
 }
@@ -1,114 +1,102 @@
 package server
 
 import (
-	"encoding/xml"
-	"net/http"
-	"strconv"
-	"strings"
-
 	"github.com/labstack/echo"
-	"github.com/osmlab/gomap/gomap"
 )
 
-// GetNode returns node by id
+// GetNode returns node by id, the version at the date is returned if at parameter is set
 func (s *Server) GetNode(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.NodeHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	date, historical, err := historicalDate(c, "at")
+	if err != nil {
 		return err
 	}
-	if err == gomap.ErrElementDeleted {
-		s.SetEmptyResultHeaders(c, http.StatusGone)
-		return err
+	if historical {
+		resp, err := s.g.NodeAtHandler(id, date)
+		if err != nil {
+			return err
+		}
+		return s.encode(c, resp)
 	}
+
+	resp, err := s.g.NodeHandler(id)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
 // GetNodes returns nodes by ids
 func (s *Server) GetNodes(c echo.Context) error {
-	rawIDs := strings.Split(c.QueryParam("nodes"), ",")
-	if len(rawIDs) == 0 {
-		s.SetEmptyResultHeaders(c, http.StatusBadRequest)
-		return nil
-	}
-
-	ids, histIDs, err := getCurrentHistoricIDs(rawIDs)
+	ids, histIDs, err := getMultiFetchIDs(c, "nodes")
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusBadRequest)
 		return err
 	}
 
 	resp, err := s.g.NodesHandler(ids, histIDs)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
-		return err
-	}
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
 // GetNodeByVersion returns node by id and version
 func (s *Server) GetNodeByVersion(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
-	version, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	version, err := parseVersion(c.Param("version"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
 	resp, err := s.g.NodeVersionHandler(id, version)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
-		return err
-	}
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
-// GetNodeHistory returns node history by id
+// GetNodeHistory returns node history by id, a page of it if range parameters are set
 func (s *Server) GetNodeHistory(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.NodeHistoryHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	r, err := parseHistoryRange(c)
+	if err != nil {
+		return err
+	}
+
+	resp, next, err := s.g.NodeHistoryHandler(id, r)
+	if err != nil {
+		return err
+	}
+	setNextPage(c, next)
+
+	return s.encodeHistory(c, resp)
+}
+
+// GetNodesHistory returns history of nodes by ids
+func (s *Server) GetNodesHistory(c echo.Context) error {
+	ids, err := getBulkIDs(c, "nodes")
+	if err != nil {
 		return err
 	}
+
+	resp, err := s.g.NodesHistoryHandler(ids)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encodeHistory(c, resp)
 }
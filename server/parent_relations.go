@@ -0,0 +1,48 @@
+package server
+
+import (
+	"github.com/labstack/echo"
+)
+
+// GetNodeRelations returns relations which have the node as a member
+func (s *Server) GetNodeRelations(c echo.Context) error {
+	return s.getParentRelations(c, "node")
+}
+
+// GetWayRelations returns relations which have the way as a member
+func (s *Server) GetWayRelations(c echo.Context) error {
+	return s.getParentRelations(c, "way")
+}
+
+// GetRelationRelations returns relations which have the relation as a member
+func (s *Server) GetRelationRelations(c echo.Context) error {
+	return s.getParentRelations(c, "relation")
+}
+
+// getParentRelations returns current parent relations, all versions of relations
+// which had the element as a member are returned if history=true is set
+func (s *Server) getParentRelations(c echo.Context, elementType string) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	history, err := parseHistoryFlag(c)
+	if err != nil {
+		return err
+	}
+
+	if history {
+		resp, err := s.g.ParentRelationsHistoryHandler(elementType, id)
+		if err != nil {
+			return err
+		}
+		return s.encodeHistory(c, resp)
+	}
+
+	resp, err := s.g.ParentRelationsHandler(elementType, id)
+	if err != nil {
+		return err
+	}
+
+	return s.encode(c, resp)
+}
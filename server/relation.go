@@ -1,140 +1,171 @@
 package server
 
 import (
-	"encoding/xml"
-	"net/http"
-	"strconv"
-	"strings"
-
 	"github.com/labstack/echo"
 	"github.com/osmlab/gomap/gomap"
 )
 
-// GetRelation returns relation by id
+// GetRelation returns relation by id, the version at the date is returned if at parameter is set
 func (s *Server) GetRelation(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.RelationHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	date, historical, err := historicalDate(c, "at")
+	if err != nil {
 		return err
 	}
-	if err == gomap.ErrElementDeleted {
-		s.SetEmptyResultHeaders(c, http.StatusGone)
-		return err
+	if historical {
+		resp, err := s.g.RelationAtHandler(id, date)
+		if err != nil {
+			return err
+		}
+		return s.encode(c, resp)
 	}
+
+	resp, err := s.g.RelationHandler(id)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
 // GetRelations returns relations by ids
 func (s *Server) GetRelations(c echo.Context) error {
-	rawIDs := strings.Split(c.QueryParam("relations"), ",")
-	if len(rawIDs) == 0 {
-		s.SetEmptyResultHeaders(c, http.StatusBadRequest)
-		return nil
-	}
-
-	ids, histIDs, err := getCurrentHistoricIDs(rawIDs)
+	ids, histIDs, err := getMultiFetchIDs(c, "relations")
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusBadRequest)
 		return err
 	}
 
 	resp, err := s.g.RelationsHandler(ids, histIDs)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
-		return err
-	}
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
-// GetRelationFull returns full relation by id
+// GetRelationFull returns full relation by id, the elements at the date are returned
+// if at parameter is set and nested relations are resolved if recurse=all is set
 func (s *Server) GetRelationFull(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.RelationFullHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	date, historical, err := historicalDate(c, "at")
+	if err != nil {
 		return err
 	}
-	if err == gomap.ErrElementDeleted {
-		s.SetEmptyResultHeaders(c, http.StatusGone)
+
+	recursive, depth, err := parseRecurse(c)
+	if err != nil {
 		return err
 	}
+	if recursive && historical {
+		return gomap.BadRequest("The parameters recurse and at can't be combined")
+	}
+
+	w, err := s.newWriter(c)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
+		return err
+	}
+	if historical {
+		if err := s.g.RelationFullAtHandler(id, date, w); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+	if recursive {
+		if err := s.g.RecursiveRelationFullHandler(id, depth, w); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+
+	if err := s.g.RelationFullHandler(id, w); err != nil {
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return w.Close()
 }
 
 // GetRelationByVersion returns relation by id and version
 func (s *Server) GetRelationByVersion(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
-	version, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	version, err := parseVersion(c.Param("version"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.NodeVersionHandler(id, version)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	resp, err := s.g.RelationVersionHandler(id, version)
+	if err != nil {
 		return err
 	}
+
+	return s.encode(c, resp)
+}
+
+// GetRelationHistory returns relation history by id, a page of it if range parameters are set
+func (s *Server) GetRelationHistory(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	r, err := parseHistoryRange(c)
+	if err != nil {
+		return err
+	}
+
+	resp, next, err := s.g.RelationHistoryHandler(id, r)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
+	setNextPage(c, next)
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encodeHistory(c, resp)
 }
 
-// GetRelationHistory returns relation history by id
-func (s *Server) GetRelationHistory(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+// GetRelationVersionFull returns full relation by id and version with members
+// as they were at the timestamp of the version
+func (s *Server) GetRelationVersionFull(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	version, err := parseVersion(c.Param("version"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.RelationHistoryHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	w, err := s.newWriter(c)
+	if err != nil {
+		return err
+	}
+	if err := s.g.RelationVersionFullHandler(id, version, w); err != nil {
 		return err
 	}
+
+	return w.Close()
+}
+
+// GetRelationsHistory returns history of relations by ids
+func (s *Server) GetRelationsHistory(c echo.Context) error {
+	ids, err := getBulkIDs(c, "relations")
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.g.RelationsHistoryHandler(ids)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encodeHistory(c, resp)
 }
@@ -1,11 +1,29 @@
 package server
 
 import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
 	"net/http"
 	"strings"
 
 	"github.com/labstack/echo"
 	"github.com/osmlab/gomap/gomap"
+	"github.com/osmlab/gomap/osm"
+)
+
+// chunkSize is the size of response chunks flushed to the client
+const chunkSize = 64 * 1024
+
+const (
+	formatXML     = "xml"
+	formatJSON    = "json"
+	formatPBF     = "pbf"
+	formatGeoJSON = "geojson"
+	formatOPL     = "opl"
+
+	mimeProtobuf = "application/x-protobuf"
+	mimeGeoJSON  = "application/geo+json"
 )
 
 // Server contains Openstreetmap API handlers
@@ -14,21 +32,205 @@ type Server struct {
 }
 
 // SetHeaders is used to set default headers for OK response
-func (s *Server) SetHeaders(c echo.Context) {
-	c.Response().Header().Set(echo.HeaderContentType, strings.ToLower(echo.MIMETextXMLCharsetUTF8))
+func (s *Server) SetHeaders(c echo.Context, contentType string) {
+	c.Response().Header().Set(echo.HeaderContentType, strings.ToLower(contentType))
 	c.Response().Header().Set("Cache-Control", "private, max-age=0, must-revalidate")
 	c.Response().WriteHeader(http.StatusOK)
 }
 
-// SetEmptyResultHeaders is used to set specific headers for empty result
-func (s *Server) SetEmptyResultHeaders(c echo.Context, status int) {
-	c.Response().Header().Set(echo.HeaderContentType, strings.ToLower(echo.MIMETextXMLCharsetUTF8))
-	c.Response().Header().Set(echo.HeaderContentLength, "0")
-	c.Response().Header().Set("Cache-Control", "no-cache")
-	c.Response().WriteHeader(status)
-}
-
 // New returns new Server
 func New(g *gomap.Gomap) *Server {
 	return &Server{g: g}
 }
+
+// responseWriter sets OK headers right before the first chunk
+// of the body and flushes every chunk to the client.
+type responseWriter struct {
+	s           *Server
+	c           echo.Context
+	contentType string
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	res := rw.c.Response()
+	if !res.Committed {
+		rw.s.SetHeaders(rw.c, rw.contentType)
+	}
+	n, err := res.Write(p)
+	if err != nil {
+		return n, err
+	}
+	res.Flush()
+	return n, nil
+}
+
+// streamWriter is osm.Writer which sends the response in chunks,
+// so the memory used by a response doesn't depend on its size.
+type streamWriter struct {
+	osm.Writer
+	buf *bufio.Writer
+	rw  *responseWriter
+}
+
+// Close finishes the osm document and flushes the rest of the response,
+// OK headers are set even if the document is empty
+func (sw *streamWriter) Close() error {
+	if err := sw.Writer.Close(); err != nil {
+		return err
+	}
+	if err := sw.buf.Flush(); err != nil {
+		return err
+	}
+	if !sw.rw.c.Response().Committed {
+		sw.rw.s.SetHeaders(sw.rw.c, sw.rw.contentType)
+	}
+	return nil
+}
+
+// WriteBounds writes bounds if the format supports them
+func (sw *streamWriter) WriteBounds(b *osm.Bounds) error {
+	if bw, ok := sw.Writer.(osm.BoundsWriter); ok {
+		return bw.WriteBounds(b)
+	}
+	return nil
+}
+
+// newWriter returns osm.Writer for the response in the requested format.
+// Nothing is sent to the client until the first chunk is filled or
+// the writer is closed, so errors can still change the response status.
+// Later errors can't, HandleError aborts the connection instead.
+func (s *Server) newWriter(c echo.Context) (*streamWriter, error) {
+	return s.newFormatWriter(c, false)
+}
+
+// newHistoryWriter returns osm.Writer for history responses, which have
+// several versions of elements, pbf files require the history support from readers
+func (s *Server) newHistoryWriter(c echo.Context) (*streamWriter, error) {
+	return s.newFormatWriter(c, true)
+}
+
+// newFormatWriter returns osm.Writer for the requested format, unknown formats are rejected
+func (s *Server) newFormatWriter(c echo.Context, historical bool) (*streamWriter, error) {
+	rw := &responseWriter{s: s, c: c}
+	buf := bufio.NewWriterSize(rw, chunkSize)
+
+	var w osm.Writer
+	switch format := responseFormat(c); format {
+	case formatJSON:
+		rw.contentType = echo.MIMEApplicationJSONCharsetUTF8
+		w = osm.NewJSONWriter(buf)
+	case formatPBF:
+		rw.contentType = mimeProtobuf
+		w = osm.NewPBFWriter(buf, historical)
+	case formatGeoJSON:
+		rw.contentType = mimeGeoJSON
+		w = osm.NewGeoJSONWriter(buf)
+	case formatOPL:
+		rw.contentType = echo.MIMETextPlainCharsetUTF8
+		w = osm.NewOPLWriter(buf)
+	case formatXML:
+		rw.contentType = echo.MIMETextXMLCharsetUTF8
+		w = osm.NewXMLWriter(buf)
+	default:
+		return nil, gomap.BadRequest("Format %q isn't supported, use xml, json, geojson, pbf or opl", format)
+	}
+
+	return &streamWriter{Writer: w, buf: buf, rw: rw}, nil
+}
+
+// encode writes osm object to the response
+func (s *Server) encode(c echo.Context, resp *osm.OSM) error {
+	w, err := s.newWriter(c)
+	if err != nil {
+		return err
+	}
+	return s.stream(w, resp)
+}
+
+// encodeHistory writes osm object with history of elements to the response
+func (s *Server) encodeHistory(c echo.Context, resp *osm.OSM) error {
+	w, err := s.newHistoryWriter(c)
+	if err != nil {
+		return err
+	}
+	return s.stream(w, resp)
+}
+
+func (s *Server) stream(w *streamWriter, resp *osm.OSM) error {
+	if err := resp.Stream(w); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// encodeDocument writes a document which isn't osm data, only xml and json are supported
+func (s *Server) encodeDocument(c echo.Context, v interface{}) error {
+	var contentType string
+	var data []byte
+	var err error
+	switch format := responseFormat(c); format {
+	case formatJSON:
+		contentType = echo.MIMEApplicationJSONCharsetUTF8
+		data, err = json.Marshal(v)
+	case formatXML:
+		contentType = echo.MIMETextXMLCharsetUTF8
+		data, err = xml.MarshalIndent(v, "", " ")
+		data = append([]byte(xml.Header), data...)
+	default:
+		return gomap.BadRequest("Format %q isn't supported by this call, use xml or json", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.SetHeaders(c, contentType)
+	_, err = c.Response().Write(data)
+	return err
+}
+
+// formatExtensions are extensions of api paths which set the response format
+var formatExtensions = map[string]bool{
+	formatXML:     true,
+	formatJSON:    true,
+	formatPBF:     true,
+	formatGeoJSON: true,
+	formatOPL:     true,
+}
+
+// FormatExtension is middleware which strips the format extension of api paths
+// like /api/0.6/map.pbf and sets it as format parameter unless the parameter is set.
+// XAPI paths like /api/0.6/node[name=foo.json] are left to RewriteXAPI.
+func (s *Server) FormatExtension(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		path := req.URL.Path
+		dot := strings.LastIndexByte(path, '.')
+		if strings.HasPrefix(path, "/api/") && !strings.ContainsRune(path, '[') &&
+			dot > strings.LastIndexByte(path, '/') && formatExtensions[path[dot+1:]] {
+			req.URL.Path, req.URL.RawPath = path[:dot], ""
+			if query := req.URL.Query(); len(query.Get("format")) == 0 {
+				query.Set("format", path[dot+1:])
+				req.URL.RawQuery = query.Encode()
+			}
+		}
+		return next(c)
+	}
+}
+
+// responseFormat returns format requested by format query parameter or Accept header
+func responseFormat(c echo.Context) string {
+	if format := c.QueryParam("format"); len(format) != 0 {
+		return format
+	}
+	accept := c.Request().Header.Get(echo.HeaderAccept)
+	if strings.Contains(accept, mimeGeoJSON) {
+		return formatGeoJSON
+	}
+	if strings.Contains(accept, echo.MIMEApplicationJSON) {
+		return formatJSON
+	}
+	if strings.Contains(accept, mimeProtobuf) {
+		return formatPBF
+	}
+	return formatXML
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+	"github.com/osmlab/gomap/osm"
+)
+
+func TestEncodeFormat(t *testing.T) {
+	cases := []struct {
+		name        string
+		target      string
+		accept      string
+		contentType string
+		status      int
+	}{
+		{name: "default", target: "/api/0.6/node/1", contentType: "text/xml; charset=utf-8"},
+		{name: "xml", target: "/api/0.6/node/1?format=xml", contentType: "text/xml; charset=utf-8"},
+		{name: "json", target: "/api/0.6/node/1?format=json", contentType: "application/json; charset=utf-8"},
+		{name: "opl", target: "/api/0.6/node/1?format=opl", contentType: "text/plain; charset=utf-8"},
+		{name: "accept json", target: "/api/0.6/node/1", accept: "application/json", contentType: "application/json; charset=utf-8"},
+		{name: "unknown format", target: "/api/0.6/node/1?format=foo", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, rec := newTestContext(tc.target)
+			if len(tc.accept) != 0 {
+				c.Request().Header.Set(echo.HeaderAccept, tc.accept)
+			}
+
+			err := (&Server{}).encode(c, osm.New())
+			if tc.status != 0 {
+				if e, ok := err.(*gomap.Error); !ok || e.Status != tc.status {
+					t.Errorf("incorrect error %v, expected status %v", err, tc.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v := rec.Header().Get(echo.HeaderContentType); v != tc.contentType {
+				t.Errorf("incorrect content type %q, expected %q", v, tc.contentType)
+			}
+		})
+	}
+}
+
+func TestFormatExtension(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+		path   string
+		format string
+	}{
+		{name: "extension", target: "/api/0.6/map.pbf?bbox=0,0,1,1", path: "/api/0.6/map", format: "pbf"},
+		{name: "format parameter", target: "/api/0.6/node/1.json?format=xml", path: "/api/0.6/node/1", format: "xml"},
+		{name: "unknown extension", target: "/api/0.6/node/1.txt", path: "/api/0.6/node/1.txt"},
+		{name: "xapi", target: "/api/0.6/node[name=foo.json]", path: "/api/0.6/node[name=foo.json]"},
+		{name: "not api", target: "/static/map.json", path: "/static/map.json"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := newTestContext(tc.target)
+			err := (&Server{}).FormatExtension(func(c echo.Context) error {
+				if path := c.Request().URL.Path; path != tc.path {
+					t.Errorf("incorrect path %q, expected %q", path, tc.path)
+				}
+				if format := c.QueryParam("format"); format != tc.format {
+					t.Errorf("incorrect format %q, expected %q", format, tc.format)
+				}
+				return nil
+			})(c)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+)
+
+const mimeVectorTile = "application/vnd.mapbox-vector-tile"
+
+// GetTile returns vector tile of map elements
+func (s *Server) GetTile(c echo.Context) error {
+	if !strings.HasSuffix(c.Param("y"), ".mvt") {
+		return echo.ErrNotFound
+	}
+
+	var zxy [3]uint32
+	params := []string{c.Param("z"), c.Param("x"), strings.TrimSuffix(c.Param("y"), ".mvt")}
+	for i := range params {
+		v, err := strconv.ParseUint(params[i], 10, 32)
+		if err != nil {
+			return gomap.BadRequest("Tile coordinates must be non-negative numbers")
+		}
+		zxy[i] = uint32(v)
+	}
+
+	tile, err := s.g.TileHandler(zxy[0], zxy[1], zxy[2])
+	if err != nil {
+		return err
+	}
+
+	s.SetHeaders(c, mimeVectorTile)
+	_, err = c.Response().Write(tile)
+	return err
+}
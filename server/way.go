@@ -1,162 +1,184 @@
 package server
 
 import (
-	"encoding/xml"
-	"net/http"
-	"strconv"
-	"strings"
-
 	"github.com/labstack/echo"
-	"github.com/osmlab/gomap/gomap"
 )
 
-// GetWay returns way by id
+// GetWay returns way by id, the version at the date is returned if at parameter is set
 func (s *Server) GetWay(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.WayHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	date, historical, err := historicalDate(c, "at")
+	if err != nil {
 		return err
 	}
-	if err == gomap.ErrElementDeleted {
-		s.SetEmptyResultHeaders(c, http.StatusGone)
-		return err
+	if historical {
+		resp, err := s.g.WayAtHandler(id, date)
+		if err != nil {
+			return err
+		}
+		return s.encode(c, resp)
 	}
+
+	resp, err := s.g.WayHandler(id)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
 // GetWays returns ways by ids
 func (s *Server) GetWays(c echo.Context) error {
-	rawIDs := strings.Split(c.QueryParam("ways"), ",")
-	if len(rawIDs) == 0 {
-		s.SetEmptyResultHeaders(c, http.StatusBadRequest)
-		return nil
-	}
-
-	ids, histIDs, err := getCurrentHistoricIDs(rawIDs)
+	ids, histIDs, err := getMultiFetchIDs(c, "ways")
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusBadRequest)
 		return err
 	}
 
 	resp, err := s.g.WaysHandler(ids, histIDs)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
-		return err
-	}
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
-// GetWayFull returns full way by id
+// GetWayFull returns full way by id, the elements at the date are returned if at parameter is set
 func (s *Server) GetWayFull(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.WayFullHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	date, historical, err := historicalDate(c, "at")
+	if err != nil {
 		return err
 	}
-	if err == gomap.ErrElementDeleted {
-		s.SetEmptyResultHeaders(c, http.StatusGone)
+
+	w, err := s.newWriter(c)
+	if err != nil {
 		return err
 	}
-	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
+	if historical {
+		if err := s.g.WayFullAtHandler(id, date, w); err != nil {
+			return err
+		}
+		return w.Close()
+	}
+
+	if err := s.g.WayFullHandler(id, w); err != nil {
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return w.Close()
 }
 
 // GetWayByVersion returns way by id and version
 func (s *Server) GetWayByVersion(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
-	version, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	version, err := parseVersion(c.Param("version"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
 	resp, err := s.g.WayVersionHandler(id, version)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
-		return err
-	}
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encode(c, resp)
 }
 
-// GetWayHistory returns way history by id
+// GetWayHistory returns way history by id, a page of it if range parameters are set
 func (s *Server) GetWayHistory(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
-	resp, err := s.g.WayHistoryHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	r, err := parseHistoryRange(c)
+	if err != nil {
 		return err
 	}
+
+	resp, next, err := s.g.WayHistoryHandler(id, r)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
+	setNextPage(c, next)
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encodeHistory(c, resp)
 }
 
-// GetWaysByNode returns ways by node
+// GetWaysByNode returns ways by node, all versions of ways which referenced the node
+// are returned if history=true is set
 func (s *Server) GetWaysByNode(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	history, err := parseHistoryFlag(c)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
 		return err
 	}
 
+	if history {
+		resp, err := s.g.NodeWaysHistoryHandler(id)
+		if err != nil {
+			return err
+		}
+		return s.encodeHistory(c, resp)
+	}
+
 	resp, err := s.g.NodeWaysHandler(id)
-	if err == gomap.ErrElementNotFound {
-		s.SetEmptyResultHeaders(c, http.StatusNotFound)
+	if err != nil {
+		return err
+	}
+
+	return s.encode(c, resp)
+}
+
+// GetWayVersionFull returns full way by id and version with members
+// as they were at the timestamp of the version
+func (s *Server) GetWayVersionFull(c echo.Context) error {
+	id, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	version, err := parseVersion(c.Param("version"))
+	if err != nil {
+		return err
+	}
+
+	w, err := s.newWriter(c)
+	if err != nil {
+		return err
+	}
+	if err := s.g.WayVersionFullHandler(id, version, w); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// GetWaysHistory returns history of ways by ids
+func (s *Server) GetWaysHistory(c echo.Context) error {
+	ids, err := getBulkIDs(c, "ways")
+	if err != nil {
 		return err
 	}
+
+	resp, err := s.g.WaysHistoryHandler(ids)
 	if err != nil {
-		s.SetEmptyResultHeaders(c, http.StatusInternalServerError)
 		return err
 	}
 
-	s.SetHeaders(c)
-	return xml.NewEncoder(c.Response()).Encode(resp)
+	return s.encodeHistory(c, resp)
 }
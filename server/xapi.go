@@ -0,0 +1,55 @@
+package server
+
+import (
+	"regexp"
+
+	"github.com/labstack/echo"
+	"github.com/osmlab/gomap/gomap"
+)
+
+const (
+	// xapiRoute is the internal route of XAPI requests
+	xapiRoute = "/api/0.6/xapi"
+
+	xapiTypeKey       = "xapi_type"
+	xapiPredicatesKey = "xapi_predicates"
+)
+
+// xapiPath matches XAPI paths like /api/0.6/way[highway=motorway|trunk][bbox=...]
+var xapiPath = regexp.MustCompile(`^/api/0\.6/(node|way|relation|\*)(\[.*\])$`)
+
+// RewriteXAPI is middleware which routes XAPI paths to GetXAPI,
+// predicates in brackets don't fit into route parameters
+func (s *Server) RewriteXAPI(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if m := xapiPath.FindStringSubmatch(req.URL.Path); m != nil {
+			c.Set(xapiTypeKey, m[1])
+			c.Set(xapiPredicatesKey, m[2])
+			req.URL.Path, req.URL.RawPath = xapiRoute, ""
+		}
+		return next(c)
+	}
+}
+
+// GetXAPI returns elements matching tag and bbox predicates of XAPI path
+func (s *Server) GetXAPI(c echo.Context) error {
+	elementType, ok := c.Get(xapiTypeKey).(string)
+	if !ok {
+		return echo.ErrNotFound
+	}
+	predicates, _ := c.Get(xapiPredicatesKey).(string)
+	q, err := gomap.ParseXAPI(elementType, predicates)
+	if err != nil {
+		return err
+	}
+
+	w, err := s.newWriter(c)
+	if err != nil {
+		return err
+	}
+	if err := s.g.XAPIHandler(q, w); err != nil {
+		return err
+	}
+	return w.Close()
+}